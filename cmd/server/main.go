@@ -9,11 +9,14 @@ import (
 	"syscall"
 
 	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
 	"go_plata_task_v2/internal/database"
 	"go_plata_task_v2/internal/external"
 	"go_plata_task_v2/internal/handlers"
 	"go_plata_task_v2/internal/logger"
 	"go_plata_task_v2/internal/middleware"
+	"go_plata_task_v2/internal/pubsub"
+	"go_plata_task_v2/internal/webhook"
 	"go_plata_task_v2/internal/worker"
 
 	_ "go_plata_task_v2/docs" // docs is generated by Swag CLI, you have to import it.
@@ -37,6 +40,14 @@ func main() {
 	// Инициализируем логгер
 	log := logger.New(cfg.Logging.Level)
 
+	// Подкоманда migrate управляет схемой базы данных и не запускает сервер
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], cfg, log); err != nil {
+			log.WithError(err).Fatal("Migration command failed")
+		}
+		return
+	}
+
 	log.WithFields(map[string]interface{}{
 		"host":      cfg.Server.Host,
 		"port":      cfg.Server.Port,
@@ -52,20 +63,44 @@ func main() {
 	}
 	defer db.Close()
 
-	// Инициализируем внешний API клиент
-	externalAPI := external.New(&cfg.External, cfg.App.SupportedCurrencies, log.Logger)
+	// Загружаем реестр валют. SUPPORTED_CURRENCIES добавляет в него недостающие валюты,
+	// дальнейшие изменения выполняются через admin API без перезапуска
+	currencies := currency.New(db, cfg.App.CurrencyRefreshInterval, log.Logger)
+	if err := currencies.Seed(cfg.App.SupportedCurrencies); err != nil {
+		log.WithError(err).Fatal("Failed to load currency registry")
+	}
+	log.WithField("currencies", currencies.Enabled()).Info("Currency registry loaded")
+
+	// Инициализируем цепочку провайдеров курсов, заданную в конфигурации
+	rateProvider, err := external.NewProvider(&cfg.External, currencies, log.Logger)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize rate providers")
+	}
+	log.WithField("providers", cfg.External.Providers).Info("Rate providers initialized")
+
+	// Создаем шину обновлений котировок между воркером и обработчиками
+	hub := pubsub.New()
 
 	// Создаем фоновый воркер
-	quoteWorker := worker.New(db, externalAPI, log.Logger, cfg.Worker.Interval)
+	quoteWorker := worker.New(db, rateProvider, hub, log.Logger, &cfg.Worker, currencies, cfg.Quotes.RatePrecision)
 
 	// Создаем контекст для graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Запускаем перезагрузку реестра валют, чтобы получать изменения других реплик
+	currencies.Start(ctx)
+	defer currencies.Stop()
+
 	// Запускаем фоновый воркер
 	quoteWorker.Start(ctx)
 	defer quoteWorker.Stop()
 
+	// Запускаем доставку webhook из outbox
+	webhookDispatcher := webhook.NewDispatcher(db, &cfg.Webhook, log.Logger)
+	webhookDispatcher.Start(ctx)
+	defer webhookDispatcher.Stop()
+
 	// Создаем роутер
 	router := mux.NewRouter()
 
@@ -78,7 +113,7 @@ func main() {
 	apiV1 := router.PathPrefix("/api/v1").Subrouter()
 
 	// Создаем обработчики и регистрируем маршруты
-	handler := handlers.New(db, log.Logger, cfg.App.SupportedCurrencies)
+	handler := handlers.New(db, log.Logger, currencies, rateProvider, cfg.Worker.Interval, cfg.Webhook, hub, cfg.Quotes, cfg.Pricing, quoteWorker, cfg.Server.WebSocketAllowedOrigins, cfg.Server.AdminToken)
 	handler.RegisterRoutes(apiV1)
 
 	// Добавляем Swagger документацию
@@ -118,8 +153,9 @@ func main() {
 
 	log.Info("Shutting down server...")
 
-	// Останавливаем воркер
+	// Останавливаем воркер и доставку webhook
 	quoteWorker.Stop()
+	webhookDispatcher.Stop()
 
 	// Создаем контекст с таймаутом для graceful shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
@@ -141,8 +177,31 @@ func printRoutes(log *logger.Logger) {
 		Desc   string
 	}{
 		{"/api/v1/quotes/update", "POST", "Обновить котировку валютной пары"},
+		{"/api/v1/quotes/update/batch", "POST", "Обновить котировки нескольких валютных пар"},
 		{"/api/v1/quotes/{id}", "GET", "Получить котировку по ID запроса"},
 		{"/api/v1/quotes/latest", "GET", "Получить последнюю котировку валютной пары"},
+		{"/api/v1/quotes/latest/batch", "GET", "Получить последние котировки нескольких валютных пар"},
+		{"/api/v1/quotes/history", "GET", "Получить историю котировок валютной пары"},
+		{"/api/v1/quotes/at", "GET", "Получить курс валютной пары на момент времени"},
+		{"/api/v1/quotes/matrix", "GET", "Матрица кросс-курсов из одного снимка курсов"},
+		{"/api/v1/quotes/stream", "GET", "Поток обновлений котировок (Server-Sent Events)"},
+		{"/api/v1/quotes/requests/{id}", "GET", "Получить состояние запроса на обновление котировки"},
+		{"/api/v1/quotes/requests/{id}/webhooks", "GET", "Журнал доставок webhook по запросу"},
+		{"/api/v1/convert", "GET", "Пересчитать сумму по последней котировке"},
+		{"/api/v1/watchlist", "GET", "Список наблюдения с расписанием автоматического обновления"},
+		{"/api/v1/watchlist", "POST", "Добавить пару в список наблюдения"},
+		{"/api/v1/watchlist/{id}", "GET", "Получить запись списка наблюдения"},
+		{"/api/v1/watchlist/{id}", "PUT", "Изменить расписание пары в списке наблюдения"},
+		{"/api/v1/watchlist/{id}", "DELETE", "Удалить пару из списка наблюдения"},
+		{"/api/v1/rates/snapshots/latest", "GET", "Последний снимок курсов относительно USD"},
+		{"/api/v1/rates/snapshots/{id}", "GET", "Снимок курсов по ID"},
+		{"/api/v1/ws", "GET", "WebSocket API котировок и запросов"},
+		{"/api/v1/admin/providers", "GET", "Состояние провайдеров курсов"},
+		{"/api/v1/currencies", "GET", "Поддерживаемые валюты"},
+		{"/api/v1/pairs", "GET", "Валютные пары с котировками"},
+		{"/api/v1/admin/currencies", "GET", "Реестр валют"},
+		{"/api/v1/admin/currencies", "POST", "Добавить валюту в реестр"},
+		{"/api/v1/admin/currencies/{code}", "PUT", "Включить, отключить или изменить валюту"},
 		{"/api/v1/health", "GET", "Health check"},
 		{"/swagger/", "GET", "Swagger документация"},
 	}
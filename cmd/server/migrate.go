@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/logger"
+)
+
+// Справка по подкоманде migrate
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down [N]   roll back the last N applied migrations (default 1)
+  status     show applied and pending migrations`
+
+// Выполняем подкоманду migrate: up, down [N] или status
+func runMigrate(args []string, cfg *config.Config, log *logger.Logger) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	db, err := database.Open(&cfg.Database, log.Logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.WithField("applied", len(applied)).Info("Migrations are up to date")
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of migrations to roll back: %s", args[1])
+			}
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.WithField("rolled_back", len(rolledBack)).Info("Migrations rolled back")
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(out, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return out.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	return nil
+}
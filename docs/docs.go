@@ -15,30 +15,33 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
+        "/admin/currencies": {
             "get": {
-                "description": "Проверка состояния сервиса",
+                "description": "Возвращает все валюты реестра с метаданными ISO 4217, включая отключённые",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "system"
+                    "admin"
                 ],
-                "summary": "Health check",
+                "summary": "Реестр валют",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.CurrenciesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/quotes/latest": {
-            "get": {
-                "description": "Возвращает последнее значение котировки для указанной валютной пары",
+            },
+            "post": {
+                "description": "Добавляет валюту без перезапуска сервиса: она сразу доступна в API и запрашивается у провайдеров при следующем обновлении.\nДля кодов из встроенного справочника ISO 4217 numeric_code, minor_units и name можно не указывать.",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,30 +49,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quotes"
+                    "admin"
                 ],
-                "summary": "Получить последнюю котировку валютной пары",
+                "summary": "Добавить валюту в реестр",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Базовая валюта (например, EUR)",
-                        "name": "from",
-                        "in": "query",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Котируемая валюта (например, MXN)",
-                        "name": "to",
-                        "in": "query",
-                        "required": true
+                        "description": "Валюта и её метаданные",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CurrencyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.QuoteResponse"
+                            "$ref": "#/definitions/models.Currency"
                         }
                     },
                     "400": {
@@ -78,8 +83,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Не передан или неверен ADMIN_TOKEN",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Валюта уже есть в реестре",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -93,9 +104,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/quotes/update": {
-            "post": {
-                "description": "Создает запрос на обновление котировки валютной пары (например, EUR/MXN). Обновление происходит в фоновом режиме.",
+        "/admin/currencies/{code}": {
+            "put": {
+                "description": "Включает или отключает валюту и меняет название или минимальные единицы. Коды ISO 4217 изменить нельзя, USD отключить нельзя.\nОтключённая валюта перестаёт приниматься API и запрашиваться у провайдеров.",
                 "consumes": [
                     "application/json"
                 ],
@@ -103,17 +114,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quotes"
+                    "admin"
                 ],
-                "summary": "Обновить котировку валютной пары",
+                "summary": "Изменить валюту реестра",
                 "parameters": [
                     {
-                        "description": "Запрос на обновление котировки",
+                        "type": "string",
+                        "description": "Bearer \u003cADMIN_TOKEN\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код валюты ISO 4217",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые значения",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateQuoteRequest"
+                            "$ref": "#/definitions/models.CurrencyRequest"
                         }
                     }
                 ],
@@ -121,7 +146,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateQuoteResponse"
+                            "$ref": "#/definitions/models.Currency"
                         }
                     },
                     "400": {
@@ -130,6 +155,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Не передан или неверен ADMIN_TOKEN",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -139,33 +176,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/quotes/{id}": {
+        "/admin/providers": {
             "get": {
-                "description": "Возвращает котировку валютной пары по ID запроса на обновление",
-                "consumes": [
+                "description": "Возвращает цепочку провайдеров курсов с состоянием circuit breaker и отметкой активного провайдера",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Состояние провайдеров курсов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert": {
+            "get": {
+                "description": "Пересчитывает сумму по последней котировке пары и округляет результат до минимальных единиц валюты to по ISO 4217.\nКоличество знаков после запятой в amount не может превышать минимальные единицы валюты from.\nУстаревшая котировка обрабатывается так же, как в /quotes/latest.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "quotes"
                 ],
-                "summary": "Получить котировку по ID запроса",
+                "summary": "Пересчитать сумму в другую валюту",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID запроса на обновление котировки",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Исходная валюта (например, EUR)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Целевая валюта (например, MXN)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сумма в исходной валюте, например 1234.56",
+                        "name": "amount",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Режим округления: half-even (по умолчанию), half-up или down",
+                        "name": "rounding",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поведение для устаревшей котировки: refresh (по умолчанию) или reject",
+                        "name": "on_stale",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.QuoteResponse"
+                            "$ref": "#/definitions/models.ConversionResponse"
                         }
                     },
                     "400": {
@@ -185,74 +271,1914 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Котировка устарела, обновление поставлено в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/models.StaleQuoteErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
+        },
+        "/currencies": {
+            "get": {
+                "description": "Возвращает валюты, которые принимают эндпоинты API, с метаданными ISO 4217. Отключённые валюты не возвращаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currencies"
+                ],
+                "summary": "Поддерживаемые валюты",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CurrenciesResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.QuoteResponse": {
-            "type": "object",
-            "properties": {
-                "from": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "rate": {
-                    "type": "number"
-                },
-                "to": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
+        "/health": {
+            "get": {
+                "description": "Проверка состояния сервиса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
         },
-        "models.UpdateQuoteRequest": {
-            "type": "object",
-            "required": [
-                "from",
-                "to"
-            ],
-            "properties": {
-                "from": {
-                    "description": "Базовая валюта (например, \"EUR\")",
-                    "type": "string"
-                },
-                "to": {
-                    "description": "Котируемая валюта (например, \"MXN\")",
-                    "type": "string"
+        "/pairs": {
+            "get": {
+                "description": "Возвращает пары включённых валют, для которых сохранена котировка, с временем последнего обновления и состоянием:\nfresh — котировка не старше допустимого возраста пары (QUOTE_MAX_AGE_PAIRS или QUOTE_MAX_AGE), stale — старше.\nВ отличие от /quotes/latest, устаревшие пары не ставятся в очередь на обновление.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currencies"
+                ],
+                "summary": "Валютные пары с котировками",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PairsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.UpdateQuoteResponse": {
-            "type": "object",
-            "properties": {
-                "from": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "to": {
-                    "type": "string"
+        "/quotes/at": {
+            "get": {
+                "description": "Возвращает курс, который был известен сервису на указанный момент времени, и его возраст на этот момент",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить курс валютной пары на момент времени",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Базовая валюта (например, EUR)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Котируемая валюта (например, MXN)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Момент времени в формате RFC3339",
+                        "name": "ts",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteAtResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/history": {
+            "get": {
+                "description": "Возвращает сохранённые значения курса валютной пары от новых к старым с курсорной пагинацией",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить историю котировок валютной пары",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Базовая валюта (например, EUR)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Котируемая валюта (например, MXN)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода в формате RFC3339 (включительно)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода в формате RFC3339 (включительно)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей на странице (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из поля next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/latest": {
+            "get": {
+                "description": "Возвращает последнее значение котировки для указанной валютной пары",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить последнюю котировку валютной пары",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Базовая валюта (например, EUR)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Котируемая валюта (например, MXN)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поведение для устаревшей котировки: refresh (по умолчанию) или reject",
+                        "name": "on_stale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Уровень клиента для наценки в bid и ask",
+                        "name": "tier",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Котировка устарела, обновление поставлено в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/models.StaleQuoteErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/latest/batch": {
+            "get": {
+                "description": "Возвращает последние котировки для списка пар (не больше 100) одним запросом к базе данных.\nОшибки (неверная пара, нет котировки, устаревшая котировка при on_stale=reject) возвращаются для каждой пары отдельно.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить последние котировки нескольких валютных пар",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Список пар через запятую, например EUR/MXN,USD/EUR",
+                        "name": "pairs",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поведение для устаревшей котировки: refresh (по умолчанию) или reject",
+                        "name": "on_stale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Уровень клиента для наценки в bid и ask",
+                        "name": "tier",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchLatestQuotesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/matrix": {
+            "get": {
+                "description": "Возвращает таблицу N×N курсов для указанных валют: rates[i][j] — курс currencies[i]/currencies[j].\nВсе курсы рассчитываются из последнего сохранённого снимка курсов относительно USD, поэтому матрица согласована.\nДля валют, которых нет в снимке, ячейки строки и столбца равны null, а сами валюты перечислены в missing.\nСнимок старше max_age (по умолчанию QUOTE_MAX_AGE) считается устаревшим: воркер получает новый, а ответ зависит от on_stale.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Матрица кросс-курсов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Валюты через запятую, например USD,EUR,MXN. По умолчанию все включённые валюты реестра в алфавитном порядке",
+                        "name": "currencies",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальный возраст снимка курсов (10m или число секунд)",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "refresh",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Поведение для устаревшего снимка: refresh (вернуть с stale=true) или reject (503)",
+                        "name": "on_stale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteMatrixResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Снимок курсов ещё не сохранён или устарел при on_stale=reject",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/requests/{id}": {
+            "get": {
+                "description": "Возвращает жизненный цикл запроса: статус, количество попыток, причину последней неудачи, временные метки и курс, которым запрос выполнен.\nПока запрос обрабатывается (pending, processing или failed с запланированным повтором), возвращается 202 с заголовком Retry-After.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить состояние запроса на обновление котировки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID запроса на обновление котировки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запрос завершён (completed или dead)",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteRequestStatusResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Запрос ещё обрабатывается",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteRequestStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/requests/{id}/webhooks": {
+            "get": {
+                "description": "Возвращает попытки доставки webhook клиента по запросу на обновление котировки, от новых к старым.\nКлиент подтверждает доступ своим секретом подписи webhook в заголовке Authorization: Bearer \u003csecret\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Получить журнал доставок webhook по запросу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID запроса на обновление котировки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID клиента",
+                        "name": "X-Client-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003cсекрет подписи webhook клиента\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDeliveriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/stream": {
+            "get": {
+                "description": "Держит SSE соединение открытым и отправляет событие quote при каждом обновлении курса подписанных пар.\nСразу после подключения отправляются текущие котировки пар, если они есть.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Поток обновлений котировок (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Список пар через запятую (например, EUR/MXN,USD/EUR)",
+                        "name": "pairs",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий quote",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteUpdateEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/update": {
+            "post": {
+                "description": "Создает запрос на обновление котировки валютной пары (например, EUR/MXN). Обновление происходит в фоновом режиме.\nЕсли указан callback_url, при переходе запроса в completed, failed или dead на него отправляется подписанный webhook.\nС параметром wait запрос обрабатывается сразу, а ответ ждёт завершения обновления: 200 с котировкой, 409 для dead запроса или 202 по истечении wait.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Обновить котировку валютной пары",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID клиента (обязателен вместе с callback_url)",
+                        "name": "X-Client-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Время ожидания обновления, например 5s (максимум 30s)",
+                        "name": "wait",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Уровень клиента для наценки в bid и ask (с wait)",
+                        "name": "tier",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Запрос на обновление котировки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запрос принят (без wait)",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateQuoteResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Обновление не завершилось за время wait",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateQuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос окончательно завершился ошибкой (с wait)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/update/batch": {
+            "post": {
+                "description": "Создает запросы на обновление котировок для списка пар (не больше 100). Для каждой пары возвращается ID запроса\nили ошибка; ошибка одной пары не влияет на остальные. Повторяющиеся пары обрабатываются один раз.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Обновить котировки нескольких валютных пар",
+                "parameters": [
+                    {
+                        "description": "Список валютных пар",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchUpdateQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchUpdateQuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/{id}": {
+            "get": {
+                "description": "Возвращает курс, которым выполнен запрос на обновление, даже если пара с тех пор обновилась. Последнюю котировку пары возвращает /quotes/latest.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Получить котировку по ID запроса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID запроса на обновление котировки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Уровень клиента для наценки в bid и ask",
+                        "name": "tier",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Запрос ещё обрабатывается, повторить через Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuoteRequestStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Запрос окончательно завершился ошибкой",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates/snapshots/latest": {
+            "get": {
+                "description": "Возвращает последний сохранённый снимок курсов относительно USD: провайдер, дата курсов по данным провайдера и время получения.\nИз снимка можно рассчитать курс любой пары поддерживаемых валют, в том числе ещё не запрошенной.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Последний снимок курсов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RateSnapshotResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Снимков курсов ещё нет",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates/snapshots/{id}": {
+            "get": {
+                "description": "Возвращает снимок курсов, на который ссылается snapshot_id котировки или записи истории",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Снимок курсов по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID снимка курсов",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RateSnapshotResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/watchlist": {
+            "get": {
+                "description": "Возвращает валютные пары, котировки которых обновляются автоматически по расписанию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Список наблюдения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет валютную пару с расписанием автоматического обновления котировки.\nУказывается либо interval (например, \"5m\"), либо schedule: \"@every 5m\" или cron выражение из пяти полей в UTC (\"*/15 9-18 * * 1-5\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Добавить пару в список наблюдения",
+                "parameters": [
+                    {
+                        "description": "Пара и расписание обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пара уже есть в списке наблюдения",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/watchlist/{id}": {
+            "get": {
+                "description": "Возвращает валютную пару из списка наблюдения с расписанием и временем следующего обновления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Получить запись списка наблюдения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID записи списка наблюдения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Изменяет расписание (interval или schedule) и признак активности. Валютную пару изменить нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Изменить запись списка наблюдения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID записи списка наблюдения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое расписание и признак активности",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Прекращает автоматическое обновление котировки валютной пары",
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Удалить пару из списка наблюдения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID записи списка наблюдения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Двусторонний канал для подписки на котировки и запуска обновлений.\nСообщения клиента: {\"type\":\"subscribe\",\"pairs\":[\"EUR/MXN\"]}, {\"type\":\"unsubscribe\",\"pairs\":[\"EUR/MXN\"]}, {\"type\":\"update\",\"from\":\"EUR\",\"to\":\"MXN\"}, {\"type\":\"ping\"}.\nСообщения сервера: subscribed, unsubscribed, quote, request_accepted, request_status, pong, error (см. models.WSServerMessage).",
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "WebSocket API котировок",
+                "responses": {
+                    "101": {
+                        "description": "Соединение переключено на WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/models.WSServerMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Источник (Origin) не разрешён",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.BatchLatestQuotesResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchQuoteResult"
+                    }
+                }
+            }
+        },
+        "models.BatchQuoteResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/models.ErrorResponse"
+                },
+                "pair": {
+                    "type": "string",
+                    "example": "EUR/MXN"
+                },
+                "quote": {
+                    "$ref": "#/definitions/models.QuoteResponse"
+                }
+            }
+        },
+        "models.BatchUpdateQuoteRequest": {
+            "type": "object",
+            "properties": {
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CurrencyPair"
+                    }
+                }
+            }
+        },
+        "models.BatchUpdateQuoteResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchUpdateQuoteResult"
+                    }
+                }
+            }
+        },
+        "models.BatchUpdateQuoteResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/models.ErrorResponse"
+                },
+                "pair": {
+                    "type": "string",
+                    "example": "EUR/MXN"
+                },
+                "request": {
+                    "$ref": "#/definitions/models.UpdateQuoteResponse"
+                }
+            }
+        },
+        "models.ConversionResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "1234.56"
+                },
+                "converted_amount": {
+                    "type": "string",
+                    "example": "26699.95"
+                },
+                "freshness": {
+                    "$ref": "#/definitions/models.QuoteFreshness"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "minor_units": {
+                    "description": "Знаков после запятой в валюте to по ISO 4217",
+                    "type": "integer",
+                    "example": 2
+                },
+                "quote_id": {
+                    "type": "string"
+                },
+                "quote_updated_at": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "rounding": {
+                    "type": "string",
+                    "example": "half-even"
+                },
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "MXN"
+                }
+            }
+        },
+        "models.CurrenciesResponse": {
+            "type": "object",
+            "properties": {
+                "currencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Currency"
+                    }
+                }
+            }
+        },
+        "models.Currency": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Буквенный код ISO 4217",
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Валюта доступна в API и запрашивается у провайдеров",
+                    "type": "boolean"
+                },
+                "minor_units": {
+                    "description": "Знаков после запятой в сумме",
+                    "type": "integer",
+                    "example": 2
+                },
+                "name": {
+                    "description": "Название валюты",
+                    "type": "string",
+                    "example": "Mexican Peso"
+                },
+                "numeric_code": {
+                    "description": "Цифровой код ISO 4217",
+                    "type": "string",
+                    "example": "484"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CurrencyPair": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CurrencyRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "BRL"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "minor_units": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Brazilian Real"
+                },
+                "numeric_code": {
+                    "type": "string",
+                    "example": "986"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PairInfo": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "max_age_seconds": {
+                    "description": "0 — возраст не ограничен",
+                    "type": "integer"
+                },
+                "pair": {
+                    "type": "string",
+                    "example": "EUR/MXN"
+                },
+                "status": {
+                    "description": "fresh или stale",
+                    "type": "string",
+                    "example": "fresh"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PairsResponse": {
+            "type": "object",
+            "properties": {
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PairInfo"
+                    }
+                }
+            }
+        },
+        "models.ProviderStatus": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_failure_at": {
+                    "type": "string"
+                },
+                "last_success_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "open_until": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "closed, open, half-open",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderStatus"
+                    }
+                }
+            }
+        },
+        "models.QuoteAtResponse": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "description": "Возраст курса на момент requested_at",
+                    "type": "integer"
+                },
+                "effective_at": {
+                    "description": "Когда курс был получен сервисом",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "requested_at": {
+                    "description": "Момент времени, на который запрошен курс",
+                    "type": "string"
+                },
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuoteFreshness": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "type": "integer"
+                },
+                "max_age_seconds": {
+                    "type": "integer"
+                },
+                "refresh_request_id": {
+                    "description": "ID запроса на обновление устаревшей котировки",
+                    "type": "string"
+                },
+                "stale": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.QuoteHistoryItem": {
+            "type": "object",
+            "properties": {
+                "rate": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.QuoteHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QuoteHistoryItem"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Передаётся в параметре cursor для следующей страницы",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuoteMatrixResponse": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "currencies": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "USD",
+                        "EUR",
+                        "MXN"
+                    ]
+                },
+                "freshness": {
+                    "description": "Возраст снимка курсов, если задан максимальный возраст",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuoteFreshness"
+                        }
+                    ]
+                },
+                "missing": {
+                    "description": "Валюты, которых нет в снимке курсов",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "MXN"
+                    ]
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "rates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.QuoteRequestStatusResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "quote": {
+                    "description": "Курс, которым выполнен запрос (только для completed)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuoteHistoryItem"
+                        }
+                    ]
+                },
+                "status": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "ask": {
+                    "type": "string",
+                    "example": "21.65955"
+                },
+                "bid": {
+                    "description": "Цены с учётом спреда пары и наценки уровня клиента, mid совпадает с rate",
+                    "type": "string",
+                    "example": "21.59465"
+                },
+                "freshness": {
+                    "$ref": "#/definitions/models.QuoteFreshness"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mid": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "snapshot_id": {
+                    "type": "integer"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tier": {
+                    "description": "Уровень клиента, наценка которого применена",
+                    "type": "string",
+                    "example": "retail"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuoteUpdateEvent": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "21.6271"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RateSnapshotResponse": {
+            "type": "object",
+            "properties": {
+                "fetched_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "rates": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "upstream_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StaleQuoteErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "quote": {
+                    "$ref": "#/definitions/models.QuoteResponse"
+                }
+            }
+        },
+        "models.UpdateQuoteRequest": {
+            "type": "object",
+            "required": [
+                "from",
+                "to"
+            ],
+            "properties": {
+                "callback_url": {
+                    "description": "Адрес для webhook о завершении запроса (требует заголовок X-Client-ID)",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "Базовая валюта (например, \"EUR\")",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Котируемая валюта (например, \"MXN\")",
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateQuoteResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WSServerMessage": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "pairs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quote": {
+                    "$ref": "#/definitions/models.QuoteUpdateEvent"
+                },
+                "request": {
+                    "$ref": "#/definitions/models.QuoteRequestStatusResponse"
+                },
+                "type": {
+                    "description": "subscribed, unsubscribed, quote, request_accepted, request_status, pong, error",
+                    "type": "string"
+                }
+            }
+        },
+        "models.WatchlistEntry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_enqueued_at": {
+                    "type": "string"
+                },
+                "last_request_id": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "\"@every 5m\" или cron выражение \"*/5 * * * *\"",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WatchlistEntryRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "5m"
+                },
+                "schedule": {
+                    "type": "string",
+                    "example": "*/15 9-18 * * 1-5"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "MXN"
+                }
+            }
+        },
+        "models.WatchlistResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WatchlistEntry"
+                    }
+                }
+            }
+        },
+        "models.WebhookDeliveriesResponse": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WebhookDelivery"
+                    }
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "callback_url": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, delivered, failed",
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
                 }
             }
         }
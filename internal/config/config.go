@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 // Config содержит конфигурацию приложения
@@ -16,6 +17,9 @@ type Config struct {
 	Database DatabaseConfig
 	External ExternalConfig
 	Worker   WorkerConfig
+	Webhook  WebhookConfig
+	Quotes   QuotesConfig
+	Pricing  PricingConfig
 	Logging  LoggingConfig
 	App      AppConfig
 }
@@ -27,6 +31,9 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	WebSocketAllowedOrigins []string // Источники (scheme://host[:port]), которым разрешено открывать WebSocket
+	AdminToken              string   // Токен для изменяющих административных эндпоинтов; пустой отключает их
 }
 
 // DatabaseConfig содержит настройки базы данных
@@ -37,18 +44,104 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	AutoMigrate bool   // Применять миграции при запуске сервера, иначе только подкомандой migrate
+	IDGenerator string // Генератор ID запросов и котировок: uuidv7 или ulid
 }
 
 // ExternalConfig содержит настройки внешнего API
 type ExternalConfig struct {
-	APIKey  string
-	BaseURL string
-	Timeout time.Duration
+	Providers []string // Упорядоченный список провайдеров: fxratesapi, ecb, openexchangerates
+	APIKey    string
+	BaseURL   string
+	Timeout   time.Duration
+
+	CircuitBreakerThreshold int           // Количество ошибок подряд до размыкания
+	CircuitBreakerCooldown  time.Duration // Время до пробного запроса к разомкнутому провайдеру
+
+	Mode                    string  // failover (по очереди) или aggregate (все провайдеры сразу)
+	AggregationMethod       string  // median или trimmed_mean
+	AggregationTrimRatio    float64 // Доля отбрасываемых значений с каждого края для trimmed_mean
+	AggregationMaxDeviation float64 // Максимальное относительное отклонение от медианы
+	AggregationMinSources   int     // Минимальное число согласованных источников
+
+	ECBURL                 string
+	OpenExchangeRatesURL   string
+	OpenExchangeRatesAppID string
 }
 
 // WorkerConfig содержит настройки фонового воркера
 type WorkerConfig struct {
 	Interval time.Duration
+
+	MaxAttempts    int           // Количество попыток обработки запроса до статуса dead
+	RetryBaseDelay time.Duration // Задержка перед первой повторной попыткой
+	RetryMaxDelay  time.Duration // Максимальная задержка между попытками
+
+	SnapshotInterval time.Duration // Как часто обновлять снимок курсов для матрицы, если запросов нет (0 — только первый снимок)
+}
+
+// WebhookConfig содержит настройки доставки webhook
+type WebhookConfig struct {
+	Secrets        map[string]string // Секреты подписи по ID клиента (заголовок X-Client-ID)
+	Interval       time.Duration     // Интервал проверки outbox
+	Timeout        time.Duration     // Таймаут HTTP запроса к получателю
+	BatchSize      int               // Количество доставок за один проход
+	MaxAttempts    int               // Количество попыток до статуса failed
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	AllowPrivateNetworks bool // Разрешить адреса webhook в частных сетях и на localhost (только для разработки)
+}
+
+// Политики обработки устаревшей котировки
+const (
+	StalePolicyRefresh = "refresh" // Отдать котировку с признаком устаревания и поставить обновление в очередь
+	StalePolicyReject  = "reject"  // Ответить 503 и поставить обновление в очередь
+)
+
+// Максимальное количество знаков после запятой в курсе, ограничено масштабом колонок rate
+const MaxRatePrecision = 12
+
+// QuotesConfig содержит настройки выдачи котировок
+type QuotesConfig struct {
+	MaxAge        time.Duration            // Максимальный возраст котировки по умолчанию (0 — без ограничения)
+	PairMaxAge    map[string]time.Duration // Максимальный возраст по валютным парам (ключ "EUR/MXN")
+	StalePolicy   string                   // refresh или reject
+	RatePrecision int32                    // Количество знаков после запятой в рассчитанных курсах
+}
+
+// MaxAgeFor возвращает максимальный возраст котировки валютной пары
+func (c QuotesConfig) MaxAgeFor(from, to string) time.Duration {
+	if maxAge, exists := c.PairMaxAge[from+"/"+to]; exists {
+		return maxAge
+	}
+	return c.MaxAge
+}
+
+// PricingConfig содержит спреды и наценки к среднему курсу в базисных пунктах (1 б.п. = 0.01%)
+type PricingConfig struct {
+	SpreadBps     decimal.Decimal            // Ширина спреда между bid и ask по умолчанию
+	PairSpreadBps map[string]decimal.Decimal // Ширина спреда по валютным парам (ключ "EUR/MXN")
+	MarkupBps     decimal.Decimal            // Наценка на каждую сторону, если уровень клиента не указан
+	TierMarkupBps map[string]decimal.Decimal // Наценка по уровням клиентов (ключ "retail")
+}
+
+// SpreadFor возвращает ширину спреда валютной пары
+func (c PricingConfig) SpreadFor(from, to string) decimal.Decimal {
+	if spread, exists := c.PairSpreadBps[from+"/"+to]; exists {
+		return spread
+	}
+	return c.SpreadBps
+}
+
+// MarkupFor возвращает наценку уровня клиента. Пустой уровень означает наценку по умолчанию
+func (c PricingConfig) MarkupFor(tier string) (decimal.Decimal, bool) {
+	if tier == "" {
+		return c.MarkupBps, true
+	}
+	markup, exists := c.TierMarkupBps[strings.ToLower(tier)]
+	return markup, exists
 }
 
 // LoggingConfig содержит настройки логирования
@@ -59,8 +152,9 @@ type LoggingConfig struct {
 
 // AppConfig содержит общие настройки приложения
 type AppConfig struct {
-	ShutdownTimeout     time.Duration
-	SupportedCurrencies []string
+	ShutdownTimeout         time.Duration
+	SupportedCurrencies     []string      // Валюты для начального заполнения реестра
+	CurrencyRefreshInterval time.Duration // Интервал перезагрузки реестра валют из БД
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -77,30 +171,79 @@ func Load() *Config {
 			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 15*time.Second),
 			IdleTimeout:  getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+
+			WebSocketAllowedOrigins: getStringSliceEnv("WS_ALLOWED_ORIGINS", nil),
+			AdminToken:              getEnv("ADMIN_TOKEN", ""),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "currency_quotes"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnv("DB_PORT", "5432"),
+			User:        getEnv("DB_USER", "postgres"),
+			Password:    getEnv("DB_PASSWORD", "postgres"),
+			DBName:      getEnv("DB_NAME", "currency_quotes"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: getBoolEnv("DB_AUTO_MIGRATE", true),
+			IDGenerator: getEnv("ID_GENERATOR", "uuidv7"),
 		},
 		External: ExternalConfig{
-			APIKey:  getEnv("EXTERNAL_API_KEY", ""),
-			BaseURL: getEnv("EXTERNAL_API_URL", "https://api.fxratesapi.com"),
-			Timeout: getDurationEnv("EXTERNAL_API_TIMEOUT", 10*time.Second),
+			Providers: getStringSliceEnv("EXTERNAL_API_PROVIDERS", []string{getEnv("EXTERNAL_API_PROVIDER", "fxratesapi")}),
+			APIKey:    getEnv("EXTERNAL_API_KEY", ""),
+			BaseURL:   getEnv("EXTERNAL_API_URL", "https://api.fxratesapi.com"),
+			Timeout:   getDurationEnv("EXTERNAL_API_TIMEOUT", 10*time.Second),
+
+			CircuitBreakerThreshold: getIntEnv("CIRCUIT_BREAKER_THRESHOLD", 3),
+			CircuitBreakerCooldown:  getDurationEnv("CIRCUIT_BREAKER_COOLDOWN", time.Minute),
+
+			Mode:                    getEnv("EXTERNAL_API_MODE", "failover"),
+			AggregationMethod:       getEnv("AGGREGATION_METHOD", "median"),
+			AggregationTrimRatio:    getFloatEnv("AGGREGATION_TRIM_RATIO", 0.2),
+			AggregationMaxDeviation: getFloatEnv("AGGREGATION_MAX_DEVIATION", 0.02),
+			AggregationMinSources:   getIntEnv("AGGREGATION_MIN_SOURCES", 1),
+
+			ECBURL:                 getEnv("ECB_API_URL", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"),
+			OpenExchangeRatesURL:   getEnv("OPENEXCHANGERATES_API_URL", "https://openexchangerates.org/api"),
+			OpenExchangeRatesAppID: getEnv("OPENEXCHANGERATES_APP_ID", ""),
 		},
 		Worker: WorkerConfig{
 			Interval: getDurationEnv("WORKER_INTERVAL", 30*time.Second),
+
+			MaxAttempts:    getIntEnv("WORKER_MAX_ATTEMPTS", 5),
+			RetryBaseDelay: getDurationEnv("WORKER_RETRY_BASE_DELAY", 10*time.Second),
+			RetryMaxDelay:  getDurationEnv("WORKER_RETRY_MAX_DELAY", 10*time.Minute),
+
+			SnapshotInterval: getDurationEnv("WORKER_SNAPSHOT_INTERVAL", 5*time.Minute),
+		},
+		Webhook: WebhookConfig{
+			Secrets:        getMapEnv("WEBHOOK_SECRETS"),
+			Interval:       getDurationEnv("WEBHOOK_INTERVAL", 5*time.Second),
+			Timeout:        getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+			BatchSize:      getIntEnv("WEBHOOK_BATCH_SIZE", 50),
+			MaxAttempts:    getIntEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBaseDelay: getDurationEnv("WEBHOOK_RETRY_BASE_DELAY", 10*time.Second),
+			RetryMaxDelay:  getDurationEnv("WEBHOOK_RETRY_MAX_DELAY", time.Hour),
+
+			AllowPrivateNetworks: getBoolEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Quotes: QuotesConfig{
+			MaxAge:        getDurationEnv("QUOTE_MAX_AGE", 0),
+			PairMaxAge:    getDurationMapEnv("QUOTE_MAX_AGE_PAIRS"),
+			StalePolicy:   getEnv("QUOTE_STALE_POLICY", StalePolicyRefresh),
+			RatePrecision: int32(clamp(getIntEnv("RATE_PRECISION", 8), 0, MaxRatePrecision)),
+		},
+		Pricing: PricingConfig{
+			SpreadBps:     getDecimalEnv("PRICING_SPREAD_BPS", decimal.Zero),
+			PairSpreadBps: getDecimalMapEnv("PRICING_SPREAD_BPS_PAIRS", strings.ToUpper),
+			MarkupBps:     getDecimalEnv("PRICING_MARKUP_BPS", decimal.Zero),
+			TierMarkupBps: getDecimalMapEnv("PRICING_MARKUP_BPS_TIERS", strings.ToLower),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
 		App: AppConfig{
-			ShutdownTimeout:     getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
-			SupportedCurrencies: getStringSliceEnv("SUPPORTED_CURRENCIES", []string{"USD", "EUR", "MXN"}),
+			ShutdownTimeout:         getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+			SupportedCurrencies:     getStringSliceEnv("SUPPORTED_CURRENCIES", []string{"USD", "EUR", "MXN"}),
+			CurrencyRefreshInterval: getDurationEnv("CURRENCY_REFRESH_INTERVAL", 30*time.Second),
 		},
 	}
 }
@@ -133,6 +276,37 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getBoolEnv получает значение переменной окружения как bool или возвращает значение по умолчанию
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// clamp ограничивает значение диапазоном [min, max]
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// getFloatEnv получает значение переменной окружения как float64 или возвращает значение по умолчанию
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getStringSliceEnv получает значение переменной окружения как slice строк или возвращает значение по умолчанию
 func getStringSliceEnv(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
@@ -140,3 +314,50 @@ func getStringSliceEnv(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getMapEnv получает значение переменной окружения в формате key1:value1,key2:value2 как map
+func getMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || name == "" || value == "" {
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// getDurationMapEnv получает значение переменной окружения в формате key1:1m,key2:30s как map длительностей
+func getDurationMapEnv(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for name, value := range getMapEnv(key) {
+		if duration, err := time.ParseDuration(value); err == nil {
+			result[strings.ToUpper(name)] = duration
+		}
+	}
+	return result
+}
+
+// getDecimalEnv получает значение переменной окружения как неотрицательное десятичное число
+// или возвращает значение по умолчанию. Строка разбирается без перевода в float64
+func getDecimalEnv(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	if value := os.Getenv(key); value != "" {
+		if decimalValue, err := decimal.NewFromString(strings.TrimSpace(value)); err == nil && !decimalValue.IsNegative() {
+			return decimalValue
+		}
+	}
+	return defaultValue
+}
+
+// getDecimalMapEnv получает значение переменной окружения в формате key1:10,key2:2.5 как map
+// неотрицательных десятичных чисел. Ключи приводятся к единому регистру функцией normalize
+func getDecimalMapEnv(key string, normalize func(string) string) map[string]decimal.Decimal {
+	result := make(map[string]decimal.Decimal)
+	for name, value := range getMapEnv(key) {
+		if decimalValue, err := decimal.NewFromString(strings.TrimSpace(value)); err == nil && !decimalValue.IsNegative() {
+			result[normalize(name)] = decimalValue
+		}
+	}
+	return result
+}
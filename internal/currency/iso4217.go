@@ -0,0 +1,60 @@
+package currency
+
+import "go_plata_task_v2/internal/models"
+
+// Встроенный справочник ISO 4217 для начального заполнения реестра и подстановки
+// метаданных при добавлении валюты через API
+var iso4217 = map[string]models.Currency{
+	"AED": {NumericCode: "784", MinorUnits: 2, Name: "UAE Dirham"},
+	"ARS": {NumericCode: "032", MinorUnits: 2, Name: "Argentine Peso"},
+	"AUD": {NumericCode: "036", MinorUnits: 2, Name: "Australian Dollar"},
+	"BGN": {NumericCode: "975", MinorUnits: 2, Name: "Bulgarian Lev"},
+	"BHD": {NumericCode: "048", MinorUnits: 3, Name: "Bahraini Dinar"},
+	"BRL": {NumericCode: "986", MinorUnits: 2, Name: "Brazilian Real"},
+	"CAD": {NumericCode: "124", MinorUnits: 2, Name: "Canadian Dollar"},
+	"CHF": {NumericCode: "756", MinorUnits: 2, Name: "Swiss Franc"},
+	"CLP": {NumericCode: "152", MinorUnits: 0, Name: "Chilean Peso"},
+	"CNY": {NumericCode: "156", MinorUnits: 2, Name: "Yuan Renminbi"},
+	"COP": {NumericCode: "170", MinorUnits: 2, Name: "Colombian Peso"},
+	"CZK": {NumericCode: "203", MinorUnits: 2, Name: "Czech Koruna"},
+	"DKK": {NumericCode: "208", MinorUnits: 2, Name: "Danish Krone"},
+	"EUR": {NumericCode: "978", MinorUnits: 2, Name: "Euro"},
+	"GBP": {NumericCode: "826", MinorUnits: 2, Name: "Pound Sterling"},
+	"HKD": {NumericCode: "344", MinorUnits: 2, Name: "Hong Kong Dollar"},
+	"HUF": {NumericCode: "348", MinorUnits: 2, Name: "Forint"},
+	"IDR": {NumericCode: "360", MinorUnits: 2, Name: "Rupiah"},
+	"ILS": {NumericCode: "376", MinorUnits: 2, Name: "New Israeli Sheqel"},
+	"INR": {NumericCode: "356", MinorUnits: 2, Name: "Indian Rupee"},
+	"ISK": {NumericCode: "352", MinorUnits: 0, Name: "Iceland Krona"},
+	"JPY": {NumericCode: "392", MinorUnits: 0, Name: "Yen"},
+	"KRW": {NumericCode: "410", MinorUnits: 0, Name: "Won"},
+	"KWD": {NumericCode: "414", MinorUnits: 3, Name: "Kuwaiti Dinar"},
+	"KZT": {NumericCode: "398", MinorUnits: 2, Name: "Tenge"},
+	"MXN": {NumericCode: "484", MinorUnits: 2, Name: "Mexican Peso"},
+	"MYR": {NumericCode: "458", MinorUnits: 2, Name: "Malaysian Ringgit"},
+	"NOK": {NumericCode: "578", MinorUnits: 2, Name: "Norwegian Krone"},
+	"NZD": {NumericCode: "554", MinorUnits: 2, Name: "New Zealand Dollar"},
+	"PEN": {NumericCode: "604", MinorUnits: 2, Name: "Sol"},
+	"PHP": {NumericCode: "608", MinorUnits: 2, Name: "Philippine Peso"},
+	"PLN": {NumericCode: "985", MinorUnits: 2, Name: "Zloty"},
+	"RON": {NumericCode: "946", MinorUnits: 2, Name: "Romanian Leu"},
+	"RUB": {NumericCode: "643", MinorUnits: 2, Name: "Russian Ruble"},
+	"SAR": {NumericCode: "682", MinorUnits: 2, Name: "Saudi Riyal"},
+	"SEK": {NumericCode: "752", MinorUnits: 2, Name: "Swedish Krona"},
+	"SGD": {NumericCode: "702", MinorUnits: 2, Name: "Singapore Dollar"},
+	"THB": {NumericCode: "764", MinorUnits: 2, Name: "Baht"},
+	"TRY": {NumericCode: "949", MinorUnits: 2, Name: "Turkish Lira"},
+	"UAH": {NumericCode: "980", MinorUnits: 2, Name: "Hryvnia"},
+	"USD": {NumericCode: "840", MinorUnits: 2, Name: "US Dollar"},
+	"VND": {NumericCode: "704", MinorUnits: 0, Name: "Dong"},
+	"ZAR": {NumericCode: "710", MinorUnits: 2, Name: "Rand"},
+}
+
+// Получаем метаданные валюты из встроенного справочника ISO 4217
+func Lookup(code string) (models.Currency, bool) {
+	currency, exists := iso4217[code]
+	if exists {
+		currency.Code = code
+	}
+	return currency, exists
+}
@@ -0,0 +1,187 @@
+package currency
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Store определяет хранилище реестра валют
+type Store interface {
+	GetCurrencies() ([]*models.Currency, error)
+	SeedCurrencies(currencies []*models.Currency) error
+}
+
+// Registry — кэш реестра валют из БД. Изменения через admin API применяются на
+// своей реплике сразу, остальные реплики получают их при периодической перезагрузке
+type Registry struct {
+	store    Store
+	logger   *logrus.Logger
+	interval time.Duration // Интервал перезагрузки реестра из БД (0 — без перезагрузки)
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.RWMutex
+	currencies map[string]*models.Currency
+}
+
+// Создаём реестр валют поверх хранилища
+func New(store Store, interval time.Duration, logger *logrus.Logger) *Registry {
+	return &Registry{
+		store:      store,
+		logger:     logger,
+		interval:   interval,
+		done:       make(chan struct{}),
+		currencies: make(map[string]*models.Currency),
+	}
+}
+
+// Создаём реестр из фиксированного списка включённых валют без хранилища
+func NewStatic(codes ...string) *Registry {
+	registry := New(nil, 0, logrus.StandardLogger())
+	for _, currency := range Defaults(codes) {
+		registry.currencies[currency.Code] = currency
+	}
+	return registry
+}
+
+// Собираем включённые валюты с метаданными ISO 4217. Для кодов, которых нет
+// во встроенном справочнике, название совпадает с кодом, а цифровой код пуст
+func Defaults(codes []string) []*models.Currency {
+	currencies := make([]*models.Currency, 0, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+
+		currency, exists := Lookup(code)
+		if !exists {
+			currency = models.Currency{Code: code, MinorUnits: utils.CurrencyExponent(code), Name: code}
+		}
+		currency.Enabled = true
+		currencies = append(currencies, &currency)
+	}
+	return currencies
+}
+
+// Добавляем в хранилище валюты, которых там ещё нет, и загружаем реестр
+func (r *Registry) Seed(codes []string) error {
+	if r.store == nil {
+		return nil
+	}
+	if err := r.store.SeedCurrencies(Defaults(codes)); err != nil {
+		return err
+	}
+	return r.Reload()
+}
+
+// Перезагружаем реестр из хранилища
+func (r *Registry) Reload() error {
+	if r.store == nil {
+		return nil
+	}
+
+	currencies, err := r.store.GetCurrencies()
+	if err != nil {
+		return err
+	}
+
+	byCode := make(map[string]*models.Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	r.mu.Lock()
+	r.currencies = byCode
+	r.mu.Unlock()
+	return nil
+}
+
+// Запускаем периодическую перезагрузку реестра
+func (r *Registry) Start(ctx context.Context) {
+	if r.store == nil || r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Reload(); err != nil {
+					r.logger.WithError(err).Error("Failed to reload currency registry")
+				}
+			case <-r.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Останавливаем перезагрузку реестра
+func (r *Registry) Stop() {
+	r.stopOnce.Do(func() { close(r.done) })
+}
+
+// Обновляем валюту в реестре после изменения в хранилище
+func (r *Registry) Put(currency *models.Currency) {
+	r.mu.Lock()
+	r.currencies[currency.Code] = currency
+	r.mu.Unlock()
+}
+
+// Получаем валюту реестра, в том числе отключённую
+func (r *Registry) Get(code string) (*models.Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	currency, exists := r.currencies[code]
+	return currency, exists
+}
+
+// Проверяем, что валюта есть в реестре и включена
+func (r *Registry) IsEnabled(code string) bool {
+	currency, exists := r.Get(code)
+	return exists && currency.Enabled
+}
+
+// Получаем включённые валюты с метаданными в алфавитном порядке кодов
+func (r *Registry) EnabledCurrencies() []*models.Currency {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	currencies := make([]*models.Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		if currency.Enabled {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// Получаем коды включённых валют в алфавитном порядке
+func (r *Registry) Enabled() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := make([]string, 0, len(r.currencies))
+	for code, currency := range r.currencies {
+		if currency.Enabled {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
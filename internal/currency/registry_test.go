@@ -0,0 +1,106 @@
+package currency
+
+import (
+	"sync"
+	"testing"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// Хранилище реестра в памяти
+type memoryStore struct {
+	mu         sync.Mutex
+	currencies map[string]*models.Currency
+}
+
+func newMemoryStore(currencies ...*models.Currency) *memoryStore {
+	store := &memoryStore{currencies: make(map[string]*models.Currency)}
+	for _, currency := range currencies {
+		store.currencies[currency.Code] = currency
+	}
+	return store
+}
+
+func (s *memoryStore) GetCurrencies() ([]*models.Currency, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var currencies []*models.Currency
+	for _, currency := range s.currencies {
+		copied := *currency
+		currencies = append(currencies, &copied)
+	}
+	return currencies, nil
+}
+
+func (s *memoryStore) SeedCurrencies(currencies []*models.Currency) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, currency := range currencies {
+		if _, exists := s.currencies[currency.Code]; !exists {
+			s.currencies[currency.Code] = currency
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) setEnabled(code string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currencies[code].Enabled = enabled
+}
+
+func TestRegistry_SeedKeepsDisabledCurrencies(t *testing.T) {
+	store := newMemoryStore(&models.Currency{Code: "MXN", NumericCode: "484", MinorUnits: 2, Name: "Mexican Peso", Enabled: false})
+	registry := New(store, 0, logrus.New())
+
+	err := registry.Seed([]string{" usd", "EUR", "MXN", ""})
+	assert.NoError(t, err)
+
+	// MXN отключена через API, повторное заполнение её не включает
+	assert.Equal(t, []string{"EUR", "USD"}, registry.Enabled())
+	assert.False(t, registry.IsEnabled("MXN"))
+
+	eur, exists := registry.Get("EUR")
+	assert.True(t, exists)
+	assert.Equal(t, "978", eur.NumericCode)
+	assert.Equal(t, "Euro", eur.Name)
+}
+
+func TestRegistry_ReloadPicksUpExternalChanges(t *testing.T) {
+	store := newMemoryStore()
+	registry := New(store, 0, logrus.New())
+	assert.NoError(t, registry.Seed([]string{"USD", "EUR"}))
+	assert.True(t, registry.IsEnabled("EUR"))
+
+	// Изменение, сделанное другой репликой, видно после перезагрузки
+	store.setEnabled("EUR", false)
+	assert.True(t, registry.IsEnabled("EUR"))
+	assert.NoError(t, registry.Reload())
+	assert.False(t, registry.IsEnabled("EUR"))
+}
+
+func TestDefaults(t *testing.T) {
+	currencies := Defaults([]string{"JPY", "xts"})
+
+	assert.Len(t, currencies, 2)
+	assert.Equal(t, models.Currency{Code: "JPY", NumericCode: "392", MinorUnits: 0, Name: "Yen", Enabled: true}, *currencies[0])
+	// Код, которого нет в справочнике, заполняется без цифрового кода
+	assert.Equal(t, models.Currency{Code: "XTS", MinorUnits: 2, Name: "XTS", Enabled: true}, *currencies[1])
+}
+
+func TestNewStatic(t *testing.T) {
+	registry := NewStatic("USD", "EUR")
+
+	assert.Equal(t, []string{"EUR", "USD"}, registry.Enabled())
+	assert.False(t, registry.IsEnabled("MXN"))
+
+	registry.Put(&models.Currency{Code: "MXN", MinorUnits: 2, Name: "Mexican Peso", Enabled: true})
+	assert.True(t, registry.IsEnabled("MXN"))
+	assert.NoError(t, registry.Reload())
+	assert.True(t, registry.IsEnabled("MXN"))
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Валюта уже есть в реестре
+var ErrCurrencyExists = errors.New("currency already exists")
+
+// Колонки валюты реестра в порядке сканирования scanCurrency
+const currencyColumns = `code, numeric_code, minor_units, name, enabled, created_at, updated_at`
+
+// Добавляем валюту в реестр
+func (db *DB) CreateCurrency(currency *models.Currency) (*models.Currency, error) {
+	query := `INSERT INTO currencies (code, numeric_code, minor_units, name, enabled, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $6) 
+			  RETURNING ` + currencyColumns
+
+	created, err := scanCurrency(db.conn.QueryRow(query,
+		currency.Code, currency.NumericCode, currency.MinorUnits, currency.Name, currency.Enabled, time.Now()))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrCurrencyExists
+		}
+		return nil, fmt.Errorf("failed to create currency: %w", err)
+	}
+
+	return created, nil
+}
+
+// Добавляем валюты, которых ещё нет в реестре. Существующие записи не меняются,
+// поэтому валюты, отключённые через API, остаются отключёнными после перезапуска
+func (db *DB) SeedCurrencies(currencies []*models.Currency) error {
+	query := `INSERT INTO currencies (code, numeric_code, minor_units, name, enabled) 
+			  VALUES ($1, $2, $3, $4, $5) 
+			  ON CONFLICT (code) DO NOTHING`
+
+	for _, currency := range currencies {
+		if _, err := db.conn.Exec(query, currency.Code, currency.NumericCode, currency.MinorUnits, currency.Name, currency.Enabled); err != nil {
+			return fmt.Errorf("failed to seed currency %s: %w", currency.Code, err)
+		}
+	}
+	return nil
+}
+
+// Получаем валюту реестра по коду
+func (db *DB) GetCurrency(code string) (*models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
+
+	currency, err := scanCurrency(db.conn.QueryRow(query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("currency %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+// Получаем все валюты реестра, включая отключённые
+func (db *DB) GetCurrencies() ([]*models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY code`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currencies: %w", err)
+	}
+	defer rows.Close()
+
+	var currencies []*models.Currency
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, currency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating currencies: %w", err)
+	}
+
+	return currencies, nil
+}
+
+// Изменяем название, минимальные единицы и признак активности валюты.
+// Коды ISO 4217 задают валюту и не меняются
+func (db *DB) UpdateCurrency(code, name string, minorUnits int, enabled bool) (*models.Currency, error) {
+	query := `UPDATE currencies 
+			  SET name = $1, minor_units = $2, enabled = $3, updated_at = $4 
+			  WHERE code = $5 
+			  RETURNING ` + currencyColumns
+
+	currency, err := scanCurrency(db.conn.QueryRow(query, name, minorUnits, enabled, time.Now(), code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("currency %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update currency: %w", err)
+	}
+
+	return currency, nil
+}
+
+// Сканируем валюту реестра из строки результата
+func scanCurrency(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.Currency, error) {
+	var currency models.Currency
+	err := row.Scan(
+		&currency.Code,
+		&currency.NumericCode,
+		&currency.MinorUnits,
+		&currency.Name,
+		&currency.Enabled,
+		&currency.CreatedAt,
+		&currency.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &currency, nil
+}
@@ -1,25 +1,65 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/idgen"
+	"go_plata_task_v2/internal/migrations"
 	"go_plata_task_v2/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
+// Запись не найдена. Оборачивает sql.ErrNoRows, чтобы вызывающий код отличал
+// отсутствие записи от ошибок базы данных через errors.Is
+var ErrNotFound = fmt.Errorf("not found: %w", sql.ErrNoRows)
+
 // Соединение с базой данных
 type DB struct {
 	conn   *sql.DB
+	ids    idgen.Generator
 	logger *logrus.Logger
 }
 
-// Создаём новое соединение с базой данных
+// Создаём новое соединение с базой данных и, если включено, применяем миграции
 func New(cfg *config.DatabaseConfig, logger *logrus.Logger) (*DB, error) {
+	db, err := Open(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.AutoMigrate {
+		return db, nil
+	}
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// Открываем соединение с базой данных без применения миграций
+func Open(cfg *config.DatabaseConfig, logger *logrus.Logger) (*DB, error) {
+	ids, err := idgen.New(cfg.IDGenerator)
+	if err != nil {
+		return nil, err
+	}
+
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
 
@@ -33,17 +73,16 @@ func New(cfg *config.DatabaseConfig, logger *logrus.Logger) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DB{
+	return &DB{
 		conn:   conn,
+		ids:    ids,
 		logger: logger,
-	}
-
-	// Создаем таблицы
-	if err := db.createTables(); err != nil {
-		return nil, fmt.Errorf("failed to create tables: %w", err)
-	}
+	}, nil
+}
 
-	return db, nil
+// Создаём мигратор схемы поверх соединения
+func (db *DB) Migrator() (*migrations.Migrator, error) {
+	return migrations.New(db.conn, db.logger)
 }
 
 // Закрываем соединение с базой данных
@@ -51,74 +90,13 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// Создаём необходимые таблицы
-func (db *DB) createTables() error {
-	// Сначала создаем таблицы
-	tableQueries := []string{
-		`CREATE TABLE IF NOT EXISTS quote_requests (
-			id VARCHAR(36) PRIMARY KEY,
-			from_currency VARCHAR(10) NOT NULL,
-			to_currency VARCHAR(10) NOT NULL,
-			status VARCHAR(20) NOT NULL DEFAULT 'pending',
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS quotes (
-			id VARCHAR(36) PRIMARY KEY,
-			from_currency VARCHAR(10) NOT NULL,
-			to_currency VARCHAR(10) NOT NULL,
-			rate DECIMAL(20,8) NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			UNIQUE(from_currency, to_currency)
-		)`,
-	}
-
-	// Создаем таблицы
-	for _, query := range tableQueries {
-		if _, err := db.conn.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute table query %s: %w", query, err)
-		}
-	}
-
-	// Создаем индексы для оптимизации и идемпотентности
-	indexQueries := []string{
-		`CREATE INDEX IF NOT EXISTS idx_quote_requests_status ON quote_requests(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_quotes_currencies ON quotes(from_currency, to_currency)`,
-		`CREATE INDEX IF NOT EXISTS idx_quote_requests_currencies ON quote_requests(from_currency, to_currency)`,
-		// Уникальный индекс для предотвращения дублирования pending запросов
-		`CREATE UNIQUE INDEX IF NOT EXISTS idx_pending_quote_requests 
-		 ON quote_requests (from_currency, to_currency) 
-		 WHERE status = 'pending'`,
-	}
-
-	for _, query := range indexQueries {
-		if _, err := db.conn.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute index query %s: %w", query, err)
-		}
-	}
-
-	return nil
-}
-
 // Создаём новый запрос на обновление котировки
 func (db *DB) CreateQuoteRequest(from, to string) (*models.QuoteRequest, error) {
 	query := `INSERT INTO quote_requests (id, from_currency, to_currency, status, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, from_currency, to_currency, status, created_at, updated_at`
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + quoteRequestColumns
 
 	now := time.Now()
-	request := &models.QuoteRequest{
-		ID:        generateID(),
-		From:      from,
-		To:        to,
-		Status:    "pending",
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-
-	err := db.conn.QueryRow(query, request.ID, request.From, request.To, request.Status, request.CreatedAt, request.UpdatedAt).
-		Scan(&request.ID, &request.From, &request.To, &request.Status, &request.CreatedAt, &request.UpdatedAt)
-
+	request, err := scanQuoteRequest(db.conn.QueryRow(query, db.ids.NewID(), from, to, "pending", now, now))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create quote request: %w", err)
 	}
@@ -136,17 +114,27 @@ func (db *DB) UpdateQuoteRequestStatus(id, status string) error {
 	return nil
 }
 
+// Помечаем запрос как выполненный и связываем его с записью истории котировок
+func (db *DB) CompleteQuoteRequest(id string, quoteHistoryID *int64) error {
+	query := `UPDATE quote_requests 
+			  SET status = 'completed', completed_at = $1, quote_history_id = $2, next_attempt_at = NULL, updated_at = $1 
+			  WHERE id = $3`
+
+	_, err := db.conn.Exec(query, time.Now(), quoteHistoryID, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete quote request: %w", err)
+	}
+	return nil
+}
+
 // Получаем запрос на обновление котировки по ID
 func (db *DB) GetQuoteRequest(id string) (*models.QuoteRequest, error) {
-	query := `SELECT id, from_currency, to_currency, status, created_at, updated_at FROM quote_requests WHERE id = $1`
-
-	request := &models.QuoteRequest{}
-	err := db.conn.QueryRow(query, id).Scan(
-		&request.ID, &request.From, &request.To, &request.Status, &request.CreatedAt, &request.UpdatedAt)
+	query := `SELECT ` + quoteRequestColumns + ` FROM quote_requests WHERE id = $1`
 
+	request, err := scanQuoteRequest(db.conn.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("quote request not found")
+			return nil, fmt.Errorf("quote request %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get quote request: %w", err)
 	}
@@ -156,17 +144,14 @@ func (db *DB) GetQuoteRequest(id string) (*models.QuoteRequest, error) {
 
 // Получаем существующий pending запрос для валютной пары
 func (db *DB) GetPendingQuoteRequestByPair(from, to string) (*models.QuoteRequest, error) {
-	query := `SELECT id, from_currency, to_currency, status, created_at, updated_at 
+	query := `SELECT ` + quoteRequestColumns + ` 
 			  FROM quote_requests 
 			  WHERE from_currency = $1 AND to_currency = $2 AND status = 'pending'`
 
-	request := &models.QuoteRequest{}
-	err := db.conn.QueryRow(query, from, to).Scan(
-		&request.ID, &request.From, &request.To, &request.Status, &request.CreatedAt, &request.UpdatedAt)
-
+	request, err := scanQuoteRequest(db.conn.QueryRow(query, from, to))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("pending quote request not found")
+			return nil, fmt.Errorf("pending quote request %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get pending quote request: %w", err)
 	}
@@ -174,33 +159,123 @@ func (db *DB) GetPendingQuoteRequestByPair(from, to string) (*models.QuoteReques
 	return request, nil
 }
 
-// Создаём новый pending запрос или возвращает существующий
-func (db *DB) CreateOrGetPendingQuoteRequest(from, to string) (*models.QuoteRequest, error) {
-	// Сначала пытаемся найти существующий pending запрос
-	existingRequest, err := db.GetPendingQuoteRequestByPair(from, to)
-	if err == nil && existingRequest != nil {
-		// Обновляем updated_at для существующего запроса
-		updateQuery := `UPDATE quote_requests SET updated_at = $1 WHERE id = $2`
-		_, err := db.conn.Exec(updateQuery, time.Now(), existingRequest.ID)
+// Фиксируем неудачную попытку обработки запроса: увеличиваем счётчик попыток,
+// сохраняем причину, ошибку и время следующей попытки (nil для окончательного отказа)
+func (db *DB) RecordQuoteRequestFailure(id, status, reason, lastError string, nextAttemptAt *time.Time) error {
+	query := `UPDATE quote_requests 
+			  SET status = $1, attempts = attempts + 1, failure_reason = $2, last_error = $3, next_attempt_at = $4, updated_at = $5 
+			  WHERE id = $6`
+
+	_, err := db.conn.Exec(query, status, reason, lastError, nextAttemptAt, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record quote request failure: %w", err)
+	}
+	return nil
+}
+
+// Возвращаем в очередь failed запросы, время повторной попытки которых наступило.
+// Для каждой пары возвращается не более одного запроса и только если у пары нет
+// другого pending запроса. Запросы переводятся по одному: pending запрос, созданный
+// одновременно с повтором, нарушает уникальный индекс idx_pending_quote_requests
+// только для своей пары, и такой запрос пропускается до следующей проверки
+func (db *DB) RequeueFailedQuoteRequests(now time.Time) (int64, error) {
+	selectQuery := `SELECT DISTINCT ON (from_currency, to_currency) id 
+			  FROM quote_requests 
+			  WHERE status = 'failed' AND next_attempt_at <= $1 
+			  ORDER BY from_currency, to_currency, next_attempt_at`
+
+	rows, err := db.conn.Query(selectQuery, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get failed quote requests due for retry: %w", err)
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan failed quote request: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate failed quote requests: %w", err)
+	}
+
+	updateQuery := `UPDATE quote_requests r 
+			  SET status = 'pending', next_attempt_at = NULL, updated_at = $1 
+			  WHERE r.id = $2 AND r.status = 'failed' 
+			  AND NOT EXISTS (
+				  SELECT 1 FROM quote_requests p 
+				  WHERE p.status = 'pending' AND p.from_currency = r.from_currency AND p.to_currency = r.to_currency
+			  )`
+
+	var count int64
+	for _, id := range ids {
+		result, err := db.conn.Exec(updateQuery, now, id)
 		if err != nil {
-			db.logger.WithError(err).WithField("request_id", existingRequest.ID).Warn("Failed to update existing request timestamp")
+			if isUniqueViolation(err) {
+				db.logger.WithField("request_id", id).Debug("Pair already has a pending quote request, skipping requeue")
+				continue
+			}
+			return count, fmt.Errorf("failed to requeue quote request %s: %w", id, err)
 		}
-		return existingRequest, nil
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return count, fmt.Errorf("failed to get requeued quote requests count: %w", err)
+		}
+		count += affected
 	}
 
-	// Если не найден, создаем новый
-	return db.CreateQuoteRequest(from, to)
+	return count, nil
+}
+
+// Проверяем, что ошибка — нарушение уникального ограничения
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
 
-// Создаём или обновляем котировку
-func (db *DB) UpsertQuote(from, to string, rate float64) error {
-	query := `INSERT INTO quotes (id, from_currency, to_currency, rate, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6) 
+// Создаём новый pending запрос или возвращаем существующий. Вставка с ON CONFLICT
+// по уникальному индексу idx_pending_quote_requests атомарна: при одновременных
+// запросах по одной паре все получают один и тот же запрос, а у существующего
+// запроса обновляется updated_at
+func (db *DB) CreateOrGetPendingQuoteRequest(from, to string) (*models.QuoteRequest, error) {
+	query := `INSERT INTO quote_requests (id, from_currency, to_currency, status, created_at, updated_at) 
+			  VALUES ($1, $2, $3, 'pending', $4, $4) 
+			  ON CONFLICT (from_currency, to_currency) WHERE status = 'pending' 
+			  DO UPDATE SET updated_at = EXCLUDED.updated_at 
+			  RETURNING ` + quoteRequestColumns
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	request, err := scanQuoteRequest(tx.QueryRow(query, db.ids.NewID(), from, to, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create or get pending quote request: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit quote request: %w", err)
+	}
+
+	return request, nil
+}
+
+// Создаём или обновляем котировку. snapshotID указывает на снимок курсов, из которого она рассчитана
+func (db *DB) UpsertQuote(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) error {
+	query := `INSERT INTO quotes (id, from_currency, to_currency, rate, sources, snapshot_id, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
 			  ON CONFLICT (from_currency, to_currency) 
-			  DO UPDATE SET rate = $4, updated_at = $6`
+			  DO UPDATE SET rate = $4, sources = $5, snapshot_id = $6, updated_at = $8`
 
 	now := time.Now()
-	_, err := db.conn.Exec(query, generateID(), from, to, rate, now, now)
+	_, err := db.conn.Exec(query, db.ids.NewID(), from, to, rate, pq.Array(sources), snapshotID, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to upsert quote: %w", err)
 	}
@@ -210,15 +285,12 @@ func (db *DB) UpsertQuote(from, to string, rate float64) error {
 
 // Получаем котировку по паре валют
 func (db *DB) GetQuote(from, to string) (*models.Quote, error) {
-	query := `SELECT id, from_currency, to_currency, rate, created_at, updated_at FROM quotes WHERE from_currency = $1 AND to_currency = $2`
-
-	quote := &models.Quote{}
-	err := db.conn.QueryRow(query, from, to).Scan(
-		&quote.ID, &quote.From, &quote.To, &quote.Rate, &quote.CreatedAt, &quote.UpdatedAt)
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE from_currency = $1 AND to_currency = $2`
 
+	quote, err := scanQuote(db.conn.QueryRow(query, from, to))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("quote not found")
+			return nil, fmt.Errorf("quote %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get quote: %w", err)
 	}
@@ -226,9 +298,139 @@ func (db *DB) GetQuote(from, to string) (*models.Quote, error) {
 	return quote, nil
 }
 
+// Получаем котировки нескольких валютных пар одним запросом. Пары без котировки пропускаются
+func (db *DB) GetQuotes(pairs []models.CurrencyPair) ([]*models.Quote, error) {
+	froms := make([]string, 0, len(pairs))
+	tos := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		froms = append(froms, pair.From)
+		tos = append(tos, pair.To)
+	}
+
+	query := `SELECT ` + quoteColumns + ` 
+			  FROM quotes 
+			  JOIN unnest($1::text[], $2::text[]) AS p(pair_from, pair_to) 
+			  ON from_currency = p.pair_from AND to_currency = p.pair_to`
+
+	rows, err := db.conn.Query(query, pq.Array(froms), pq.Array(tos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quotes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanQuotes(rows)
+}
+
+// Получаем последние котировки всех валютных пар
+func (db *DB) ListQuotes() ([]*models.Quote, error) {
+	query := `SELECT ` + quoteColumns + ` FROM quotes ORDER BY from_currency, to_currency`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanQuotes(rows)
+}
+
+// Добавляем запись в историю котировок
+func (db *DB) AppendQuoteHistory(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) (int64, error) {
+	query := `INSERT INTO quote_history (from_currency, to_currency, rate, sources, snapshot_id, recorded_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	var id int64
+	err := db.conn.QueryRow(query, from, to, rate, pq.Array(sources), snapshotID, time.Now()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append quote history: %w", err)
+	}
+
+	return id, nil
+}
+
+// Получаем запись истории котировок по ID
+func (db *DB) GetQuoteHistoryEntry(id int64) (*models.QuoteHistoryEntry, error) {
+	query := `SELECT ` + quoteHistoryColumns + ` FROM quote_history WHERE id = $1`
+
+	entry, err := scanQuoteHistoryEntry(db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quote history entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quote history entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Получаем историю котировок валютной пары, от новых к старым
+func (db *DB) GetQuoteHistory(filter models.QuoteHistoryFilter) ([]*models.QuoteHistoryEntry, error) {
+	conditions := []string{"from_currency = $1", "to_currency = $2"}
+	args := []interface{}{filter.From, filter.To}
+
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conditions = append(conditions, fmt.Sprintf("recorded_at >= $%d", len(args)))
+	}
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		conditions = append(conditions, fmt.Sprintf("recorded_at <= $%d", len(args)))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.RecordedAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(recorded_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`SELECT `+quoteHistoryColumns+`
+			  FROM quote_history
+			  WHERE %s
+			  ORDER BY recorded_at DESC, id DESC
+			  LIMIT $%d`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.QuoteHistoryEntry
+	for rows.Next() {
+		entry, err := scanQuoteHistoryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quote history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate quote history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Получаем курс валютной пары, действовавший на указанный момент времени
+func (db *DB) GetQuoteAt(from, to string, at time.Time) (*models.QuoteHistoryEntry, error) {
+	query := `SELECT ` + quoteHistoryColumns + `
+			  FROM quote_history
+			  WHERE from_currency = $1 AND to_currency = $2 AND recorded_at <= $3
+			  ORDER BY recorded_at DESC, id DESC
+			  LIMIT 1`
+
+	entry, err := scanQuoteHistoryEntry(db.conn.QueryRow(query, from, to, at))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quote %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quote at %s: %w", at.Format(time.RFC3339), err)
+	}
+
+	return entry, nil
+}
+
 // Получаем все ожидающие запросы на обновление котировок
 func (db *DB) GetPendingQuoteRequests() ([]*models.QuoteRequest, error) {
-	query := `SELECT id, from_currency, to_currency, status, created_at, updated_at FROM quote_requests WHERE status = 'pending' ORDER BY created_at ASC`
+	query := `SELECT ` + quoteRequestColumns + ` FROM quote_requests WHERE status = 'pending' ORDER BY created_at ASC`
 
 	rows, err := db.conn.Query(query)
 	if err != nil {
@@ -238,8 +440,7 @@ func (db *DB) GetPendingQuoteRequests() ([]*models.QuoteRequest, error) {
 
 	var requests []*models.QuoteRequest
 	for rows.Next() {
-		request := &models.QuoteRequest{}
-		err := rows.Scan(&request.ID, &request.From, &request.To, &request.Status, &request.CreatedAt, &request.UpdatedAt)
+		request, err := scanQuoteRequest(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan quote request: %w", err)
 		}
@@ -249,7 +450,97 @@ func (db *DB) GetPendingQuoteRequests() ([]*models.QuoteRequest, error) {
 	return requests, nil
 }
 
-// Генерируем уникальный ID
-func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// Колонки котировки в порядке сканирования scanQuote
+const quoteColumns = `id, from_currency, to_currency, rate, sources, snapshot_id, created_at, updated_at`
+
+// Сканируем котировки из результата запроса
+func scanQuotes(rows *sql.Rows) ([]*models.Quote, error) {
+	var quotes []*models.Quote
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quote: %w", err)
+		}
+		quotes = append(quotes, quote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quotes: %w", err)
+	}
+
+	return quotes, nil
+}
+
+// Сканируем котировку из строки результата
+func scanQuote(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.Quote, error) {
+	quote := &models.Quote{}
+	var snapshotID sql.NullInt64
+
+	err := row.Scan(&quote.ID, &quote.From, &quote.To, &quote.Rate, pq.Array(&quote.Sources),
+		&snapshotID, &quote.CreatedAt, &quote.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotID.Valid {
+		quote.SnapshotID = &snapshotID.Int64
+	}
+
+	return quote, nil
+}
+
+// Колонки записи истории котировок в порядке сканирования scanQuoteHistoryEntry
+const quoteHistoryColumns = `id, from_currency, to_currency, rate, sources, snapshot_id, recorded_at`
+
+// Сканируем запись истории котировок из строки результата
+func scanQuoteHistoryEntry(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.QuoteHistoryEntry, error) {
+	entry := &models.QuoteHistoryEntry{}
+	var snapshotID sql.NullInt64
+
+	err := row.Scan(&entry.ID, &entry.From, &entry.To, &entry.Rate, pq.Array(&entry.Sources),
+		&snapshotID, &entry.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotID.Valid {
+		entry.SnapshotID = &snapshotID.Int64
+	}
+
+	return entry, nil
+}
+
+// Колонки запроса на обновление котировки в порядке сканирования scanQuoteRequest
+const quoteRequestColumns = `id, from_currency, to_currency, status, attempts, next_attempt_at, failure_reason, last_error, completed_at, quote_history_id, created_at, updated_at`
+
+// Сканируем запрос на обновление котировки из строки результата
+func scanQuoteRequest(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.QuoteRequest, error) {
+	request := &models.QuoteRequest{}
+	var nextAttemptAt, completedAt sql.NullTime
+	var quoteHistoryID sql.NullInt64
+
+	err := row.Scan(&request.ID, &request.From, &request.To, &request.Status,
+		&request.Attempts, &nextAttemptAt, &request.FailureReason, &request.LastError,
+		&completedAt, &quoteHistoryID, &request.CreatedAt, &request.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextAttemptAt.Valid {
+		request.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if completedAt.Valid {
+		request.CompletedAt = &completedAt.Time
+	}
+	if quoteHistoryID.Valid {
+		request.QuoteHistoryID = &quoteHistoryID.Int64
+	}
+
+	return request, nil
 }
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"go_plata_task_v2/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// Подключаемся к тестовой базе PostgreSQL. Тест пропускается, если TEST_DB_NAME не задана;
+// остальные параметры подключения берутся из переменных DB_*
+func openTestDB(t *testing.T) *DB {
+	dbName := os.Getenv("TEST_DB_NAME")
+	if dbName == "" {
+		t.Skip("TEST_DB_NAME is not set, skipping database integration test")
+	}
+
+	cfg := config.Load().Database
+	cfg.DBName = dbName
+	cfg.AutoMigrate = true
+
+	db, err := New(&cfg, logrus.New())
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCreateOrGetPendingQuoteRequest_Concurrent(t *testing.T) {
+	const (
+		clients = 50
+		from    = "TST"
+		to      = "RACE"
+	)
+
+	db := openTestDB(t)
+
+	cleanup := func() {
+		if _, err := db.conn.Exec(`DELETE FROM quote_requests WHERE from_currency = $1 AND to_currency = $2`, from, to); err != nil {
+			t.Fatalf("failed to clean up quote requests: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	ids := make([]string, clients)
+	errs := make([]error, clients)
+
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			request, err := db.CreateOrGetPendingQuoteRequest(from, to)
+			errs[i] = err
+			if err == nil {
+				ids[i] = request.ID
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	for i := 0; i < clients; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+
+	var pending int
+	assert.NoError(t, db.conn.QueryRow(
+		`SELECT COUNT(*) FROM quote_requests WHERE from_currency = $1 AND to_currency = $2 AND status = 'pending'`,
+		from, to).Scan(&pending))
+	assert.Equal(t, 1, pending)
+
+	// После завершения запроса по паре создаётся новый pending запрос
+	assert.NoError(t, db.UpdateQuoteRequestStatus(ids[0], "completed"))
+	next, err := db.CreateOrGetPendingQuoteRequest(from, to)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEqual(t, ids[0], next.ID)
+	assert.Equal(t, "pending", next.Status)
+}
@@ -1,6 +1,12 @@
 package database
 
-import "go_plata_task_v2/internal/models"
+import (
+	"time"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/shopspring/decimal"
+)
 
 // DatabaseInterface определяет интерфейс для работы с базой данных
 type DatabaseInterface interface {
@@ -9,9 +15,39 @@ type DatabaseInterface interface {
 	GetQuoteRequest(id string) (*models.QuoteRequest, error)
 	GetPendingQuoteRequestByPair(from, to string) (*models.QuoteRequest, error)
 	GetQuote(from, to string) (*models.Quote, error)
+	GetQuotes(pairs []models.CurrencyPair) ([]*models.Quote, error)
+	ListQuotes() ([]*models.Quote, error)
 	UpdateQuoteRequestStatus(id, status string) error
-	UpsertQuote(from, to string, rate float64) error
+	CompleteQuoteRequest(id string, quoteHistoryID *int64) error
+	RecordQuoteRequestFailure(id, status, reason, lastError string, nextAttemptAt *time.Time) error
+	RequeueFailedQuoteRequests(now time.Time) (int64, error)
+	UpsertQuote(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) error
 	GetPendingQuoteRequests() ([]*models.QuoteRequest, error)
+	AppendQuoteHistory(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) (int64, error)
+	GetQuoteHistoryEntry(id int64) (*models.QuoteHistoryEntry, error)
+	GetQuoteHistory(filter models.QuoteHistoryFilter) ([]*models.QuoteHistoryEntry, error)
+	GetQuoteAt(from, to string, at time.Time) (*models.QuoteHistoryEntry, error)
+	AddWebhookSubscription(requestID, clientID, callbackURL string) (*models.WebhookSubscription, error)
+	EnqueueWebhookDeliveries(requestID, event string, payload []byte) (int64, error)
+	ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	MarkWebhookDelivered(id int64, statusCode int) error
+	RecordWebhookDeliveryFailure(id int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error
+	GetWebhookDeliveries(requestID, clientID string) ([]*models.WebhookDelivery, error)
+	CreateWatchlistEntry(from, to, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error)
+	GetWatchlistEntry(id int64) (*models.WatchlistEntry, error)
+	GetWatchlist() ([]*models.WatchlistEntry, error)
+	UpdateWatchlistEntry(id int64, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error)
+	DeleteWatchlistEntry(id int64) error
+	GetDueWatchlistEntries(now time.Time) ([]*models.WatchlistEntry, error)
+	MarkWatchlistEntryEnqueued(id int64, runAt time.Time, requestID string, nextRunAt time.Time) error
+	SaveRateSnapshot(snapshot *models.RatesSnapshot) (int64, error)
+	GetRateSnapshot(id int64) (*models.RatesSnapshot, error)
+	GetLatestRateSnapshot() (*models.RatesSnapshot, error)
+	CreateCurrency(currency *models.Currency) (*models.Currency, error)
+	SeedCurrencies(currencies []*models.Currency) error
+	GetCurrency(code string) (*models.Currency, error)
+	GetCurrencies() ([]*models.Currency, error)
+	UpdateCurrency(code, name string, minorUnits int, enabled bool) (*models.Currency, error)
 	Close() error
 }
 
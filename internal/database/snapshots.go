@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"go_plata_task_v2/internal/models"
+)
+
+// Колонки снимка курсов в порядке сканирования scanRateSnapshot
+const rateSnapshotColumns = `id, provider, upstream_date, rates, sources, fetched_at`
+
+// Сохраняем снимок курсов относительно USD, полученный за один запрос к провайдерам
+func (db *DB) SaveRateSnapshot(snapshot *models.RatesSnapshot) (int64, error) {
+	rates, err := json.Marshal(snapshot.Rates.Rates)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal snapshot rates: %w", err)
+	}
+
+	sources := snapshot.Rates.Sources
+	if sources == nil {
+		sources = map[string][]string{}
+	}
+	sourcesJSON, err := json.Marshal(sources)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal snapshot sources: %w", err)
+	}
+
+	query := `INSERT INTO rate_snapshots (provider, upstream_date, rates, sources, fetched_at) 
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var id int64
+	err = db.conn.QueryRow(query, snapshot.Rates.Provider, snapshot.Rates.Date, rates, sourcesJSON, snapshot.FetchedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save rate snapshot: %w", err)
+	}
+
+	return id, nil
+}
+
+// Получаем снимок курсов по ID
+func (db *DB) GetRateSnapshot(id int64) (*models.RatesSnapshot, error) {
+	query := `SELECT ` + rateSnapshotColumns + ` FROM rate_snapshots WHERE id = $1`
+
+	snapshot, err := scanRateSnapshot(db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rate snapshot %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get rate snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// Получаем последний сохранённый снимок курсов
+func (db *DB) GetLatestRateSnapshot() (*models.RatesSnapshot, error) {
+	query := `SELECT ` + rateSnapshotColumns + ` FROM rate_snapshots ORDER BY fetched_at DESC, id DESC LIMIT 1`
+
+	snapshot, err := scanRateSnapshot(db.conn.QueryRow(query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rate snapshot %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get latest rate snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// Сканируем снимок курсов из строки результата
+func scanRateSnapshot(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.RatesSnapshot, error) {
+	snapshot := &models.RatesSnapshot{Rates: &models.ExchangeRates{}}
+	var rates, sources []byte
+
+	err := row.Scan(&snapshot.ID, &snapshot.Rates.Provider, &snapshot.Rates.Date, &rates, &sources, &snapshot.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rates, &snapshot.Rates.Rates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot rates: %w", err)
+	}
+	if err := json.Unmarshal(sources, &snapshot.Rates.Sources); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot sources: %w", err)
+	}
+
+	return snapshot, nil
+}
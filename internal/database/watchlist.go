@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Пара уже есть в списке наблюдения
+var ErrWatchlistEntryExists = errors.New("watchlist entry already exists")
+
+// Колонки записи списка наблюдения в порядке сканирования scanWatchlistEntry
+const watchlistColumns = `id, from_currency, to_currency, schedule, enabled, next_run_at, last_enqueued_at, last_request_id, created_at, updated_at`
+
+// Добавляем пару в список наблюдения
+func (db *DB) CreateWatchlistEntry(from, to, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error) {
+	query := `INSERT INTO watchlist (from_currency, to_currency, schedule, enabled, next_run_at, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $6) 
+			  RETURNING ` + watchlistColumns
+
+	entry, err := scanWatchlistEntry(db.conn.QueryRow(query, from, to, schedule, enabled, nextRunAt, time.Now()))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrWatchlistEntryExists
+		}
+		return nil, fmt.Errorf("failed to create watchlist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Получаем запись списка наблюдения по ID
+func (db *DB) GetWatchlistEntry(id int64) (*models.WatchlistEntry, error) {
+	query := `SELECT ` + watchlistColumns + ` FROM watchlist WHERE id = $1`
+
+	entry, err := scanWatchlistEntry(db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("watchlist entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get watchlist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Получаем весь список наблюдения
+func (db *DB) GetWatchlist() ([]*models.WatchlistEntry, error) {
+	query := `SELECT ` + watchlistColumns + ` FROM watchlist ORDER BY from_currency, to_currency`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWatchlistEntries(rows)
+}
+
+// Изменяем расписание и признак активности записи списка наблюдения
+func (db *DB) UpdateWatchlistEntry(id int64, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error) {
+	query := `UPDATE watchlist 
+			  SET schedule = $1, enabled = $2, next_run_at = $3, updated_at = $4 
+			  WHERE id = $5 
+			  RETURNING ` + watchlistColumns
+
+	entry, err := scanWatchlistEntry(db.conn.QueryRow(query, schedule, enabled, nextRunAt, time.Now(), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("watchlist entry %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update watchlist entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Удаляем пару из списка наблюдения
+func (db *DB) DeleteWatchlistEntry(id int64) error {
+	query := `DELETE FROM watchlist WHERE id = $1`
+
+	if _, err := db.conn.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete watchlist entry: %w", err)
+	}
+	return nil
+}
+
+// Получаем активные записи списка наблюдения, время обновления которых наступило
+func (db *DB) GetDueWatchlistEntries(now time.Time) ([]*models.WatchlistEntry, error) {
+	query := `SELECT ` + watchlistColumns + ` 
+			  FROM watchlist 
+			  WHERE enabled AND next_run_at <= $1 
+			  ORDER BY next_run_at`
+
+	rows, err := db.conn.Query(query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due watchlist entries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWatchlistEntries(rows)
+}
+
+// Фиксируем постановку обновления пары в очередь и время следующего запуска.
+// Условие на next_run_at не даёт двум репликам воркера сдвинуть расписание дважды
+func (db *DB) MarkWatchlistEntryEnqueued(id int64, runAt time.Time, requestID string, nextRunAt time.Time) error {
+	query := `UPDATE watchlist 
+			  SET last_enqueued_at = $1, last_request_id = $2, next_run_at = $3, updated_at = $1 
+			  WHERE id = $4 AND next_run_at <= $1`
+
+	_, err := db.conn.Exec(query, runAt, requestID, nextRunAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark watchlist entry enqueued: %w", err)
+	}
+	return nil
+}
+
+// Сканируем записи списка наблюдения из результата запроса
+func scanWatchlistEntries(rows *sql.Rows) ([]*models.WatchlistEntry, error) {
+	var entries []*models.WatchlistEntry
+	for rows.Next() {
+		entry, err := scanWatchlistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchlist: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Сканируем запись списка наблюдения из строки результата
+func scanWatchlistEntry(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.WatchlistEntry, error) {
+	entry := &models.WatchlistEntry{}
+	var lastEnqueuedAt sql.NullTime
+
+	err := row.Scan(&entry.ID, &entry.From, &entry.To, &entry.Schedule, &entry.Enabled,
+		&entry.NextRunAt, &lastEnqueuedAt, &entry.LastRequestID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastEnqueuedAt.Valid {
+		entry.LastEnqueuedAt = &lastEnqueuedAt.Time
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+)
+
+// Колонки доставки webhook в порядке сканирования scanWebhookDelivery
+const webhookDeliveryColumns = `id, subscription_id, request_id, client_id, callback_url, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+// Подписываем клиента на изменения статуса запроса. Повторная подписка с тем же
+// адресом возвращает существующую подписку
+func (db *DB) AddWebhookSubscription(requestID, clientID, callbackURL string) (*models.WebhookSubscription, error) {
+	query := `INSERT INTO webhook_subscriptions (request_id, client_id, callback_url, created_at) 
+			  VALUES ($1, $2, $3, $4) 
+			  ON CONFLICT (request_id, client_id, callback_url) DO UPDATE SET callback_url = EXCLUDED.callback_url 
+			  RETURNING id, request_id, client_id, callback_url, created_at`
+
+	subscription := &models.WebhookSubscription{}
+	err := db.conn.QueryRow(query, requestID, clientID, callbackURL, time.Now()).Scan(
+		&subscription.ID, &subscription.RequestID, &subscription.ClientID, &subscription.CallbackURL, &subscription.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// Добавляем в outbox доставки события для всех подписчиков запроса. Доставка привязана
+// к текущему updated_at запроса, поэтому повторная постановка того же события
+// подписчику, который его уже получил, пропускается
+func (db *DB) EnqueueWebhookDeliveries(requestID, event string, payload []byte) (int64, error) {
+	query := `INSERT INTO webhook_deliveries (subscription_id, request_id, client_id, callback_url, event, payload, status, next_attempt_at, created_at, status_changed_at) 
+			  SELECT s.id, s.request_id, s.client_id, s.callback_url, $2, $3, 'pending', $4, $4, r.updated_at 
+			  FROM webhook_subscriptions s 
+			  JOIN quote_requests r ON r.id = s.request_id 
+			  WHERE s.request_id = $1 
+			  ON CONFLICT (subscription_id, event, status_changed_at) DO NOTHING`
+
+	result, err := db.conn.Exec(query, requestID, event, string(payload), time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get enqueued webhook deliveries count: %w", err)
+	}
+
+	return count, nil
+}
+
+// Забираем доставки, время отправки которых наступило. Время следующей попытки
+// сдвигается на lease, чтобы доставку не забрала другая реплика, а при падении
+// процесса она была повторена после истечения lease
+func (db *DB) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries 
+			  SET next_attempt_at = $2 
+			  WHERE id IN (
+				  SELECT id FROM webhook_deliveries 
+				  WHERE status = 'pending' AND next_attempt_at <= $1 
+				  ORDER BY next_attempt_at 
+				  LIMIT $3 
+				  FOR UPDATE SKIP LOCKED
+			  ) 
+			  RETURNING ` + webhookDeliveryColumns
+
+	rows, err := db.conn.Query(query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// Помечаем доставку как успешную
+func (db *DB) MarkWebhookDelivered(id int64, statusCode int) error {
+	query := `UPDATE webhook_deliveries 
+			  SET status = 'delivered', attempts = attempts + 1, last_status_code = $1, last_error = '', delivered_at = $2 
+			  WHERE id = $3`
+
+	_, err := db.conn.Exec(query, statusCode, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// Фиксируем неудачную попытку доставки: pending с временем следующей попытки
+// или failed, если попытки исчерпаны
+func (db *DB) RecordWebhookDeliveryFailure(id int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE webhook_deliveries 
+			  SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = $3, next_attempt_at = $4 
+			  WHERE id = $5`
+
+	_, err := db.conn.Exec(query, status, statusCode, lastError, nextAttemptAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery failure: %w", err)
+	}
+	return nil
+}
+
+// Получаем журнал доставок webhook клиента по запросу, от новых к старым
+func (db *DB) GetWebhookDeliveries(requestID, clientID string) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` 
+			  FROM webhook_deliveries 
+			  WHERE request_id = $1 AND client_id = $2 
+			  ORDER BY created_at DESC, id DESC`
+
+	rows, err := db.conn.Query(query, requestID, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// Сканируем доставки webhook из результата запроса
+func scanWebhookDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		var payload string
+		var deliveredAt sql.NullTime
+
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.RequestID, &delivery.ClientID,
+			&delivery.CallbackURL, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+			&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &deliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+
+		delivery.Payload = []byte(payload)
+		if deliveredAt.Valid {
+			delivery.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,275 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Название агрегирующего провайдера
+const ProviderAggregate = "aggregate"
+
+// Способы объединения курсов нескольких провайдеров
+const (
+	AggregationMedian      = "median"
+	AggregationTrimmedMean = "trimmed_mean"
+)
+
+// Минимальное число источников, при котором выбросы определяются по медиане.
+// При двух источниках медиана — их середина, и один ошибочный курс
+// отбросил бы оба значения
+const minOutlierSources = 3
+
+// Причины, по которым курс валюты не попал в объединённые курсы
+var (
+	errRateMissing         = errors.New("no provider returned rate")
+	errInsufficientSources = errors.New("not enough agreeing rate sources")
+)
+
+// Форматы дат, которые возвращают провайдеры
+var providerDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// Разбираем дату курсов провайдера
+func parseProviderDate(value string) (time.Time, bool) {
+	for _, layout := range providerDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Настройки агрегации курсов
+type AggregationOptions struct {
+	Method       string  // median или trimmed_mean
+	TrimRatio    float64 // Доля значений, отбрасываемая с каждого края для trimmed_mean
+	MaxDeviation float64 // Максимальное относительное отклонение от медианы (0.02 = 2%)
+	MinSources   int     // Минимальное число согласованных источников для валюты
+}
+
+// Агрегирующий провайдер: параллельно опрашивает всех провайдеров
+// и объединяет их курсы, отбрасывая выбросы
+type AggregatingProvider struct {
+	mu        sync.Mutex
+	providers []RateProvider
+	breakers  []*circuitBreaker
+	options   AggregationOptions
+	active    map[string]bool
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// Убеждаемся, что агрегатор реализует MonitoredProvider
+var _ MonitoredProvider = (*AggregatingProvider)(nil)
+
+// Создаём агрегирующий провайдер
+func NewAggregating(providers []RateProvider, options AggregationOptions, threshold int, cooldown time.Duration, logger *logrus.Logger) *AggregatingProvider {
+	if options.MinSources < 1 {
+		options.MinSources = 1
+	}
+
+	aggregator := &AggregatingProvider{
+		providers: providers,
+		options:   options,
+		active:    make(map[string]bool),
+		logger:    logger,
+		now:       time.Now,
+	}
+
+	for _, provider := range providers {
+		aggregator.breakers = append(aggregator.breakers,
+			newCircuitBreaker(provider.Name(), threshold, cooldown, func() time.Time { return aggregator.now() }, logger))
+	}
+
+	return aggregator
+}
+
+// Название провайдера
+func (a *AggregatingProvider) Name() string {
+	return ProviderAggregate
+}
+
+// Результат опроса одного провайдера
+type providerResult struct {
+	name  string
+	rates map[string]decimal.Decimal
+	date  string
+	err   error
+}
+
+// Получаем курсы у всех доступных провайдеров и объединяем их
+func (a *AggregatingProvider) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
+	results := make([]*providerResult, len(a.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range a.providers {
+		if !a.breakers[i].allow() {
+			a.logger.WithField("provider", provider.Name()).Debug("Skipping rate provider with open circuit")
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, provider RateProvider) {
+			defer wg.Done()
+
+			result := &providerResult{name: provider.Name()}
+			rates, err := provider.GetMultipleExchangeRates(currencies)
+			if err != nil {
+				result.err = err
+			} else {
+				result.rates = rates.Rates
+				result.date = rates.Date
+			}
+			results[i] = result
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var succeeded []*providerResult
+	var errs []string
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		if result.err != nil {
+			a.breakers[i].recordFailure(result.err)
+			a.logger.WithError(result.err).WithField("provider", result.name).Warn("Rate provider failed during aggregation")
+			errs = append(errs, fmt.Sprintf("%s: %v", result.name, result.err))
+			continue
+		}
+		a.breakers[i].recordSuccess()
+		succeeded = append(succeeded, result)
+	}
+
+	if len(succeeded) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("all rate providers are unavailable (circuit open)")
+		}
+		return nil, fmt.Errorf("all rate providers failed: %s", strings.Join(errs, "; "))
+	}
+
+	aggregated := &models.ExchangeRates{
+		Provider: ProviderAggregate,
+		Rates:    map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)},
+		Sources:  make(map[string][]string),
+	}
+
+	// Датой объединённых курсов считаем самую раннюю дату среди провайдеров.
+	// Провайдеры возвращают даты в разных форматах, поэтому сравниваем их как время
+	var earliest time.Time
+	for _, result := range succeeded {
+		date, ok := parseProviderDate(result.date)
+		if !ok {
+			continue
+		}
+		if aggregated.Date == "" || date.Before(earliest) {
+			earliest = date
+			aggregated.Date = result.date
+		}
+	}
+
+	active := make(map[string]bool)
+	for _, currency := range symbolsWithoutUSD(currencies) {
+		rate, sources, err := a.aggregateCurrency(currency, succeeded)
+		if errors.Is(err, errInsufficientSources) {
+			aggregated.InsufficientSources = append(aggregated.InsufficientSources, currency)
+		}
+		if err != nil {
+			continue
+		}
+
+		aggregated.Rates[currency] = rate
+		aggregated.Sources[currency] = sources
+		for _, source := range sources {
+			active[source] = true
+		}
+	}
+
+	a.mu.Lock()
+	a.active = active
+	a.mu.Unlock()
+
+	a.logger.WithFields(logrus.Fields{
+		"providers":   len(succeeded),
+		"rates_count": len(aggregated.Rates),
+	}).Info("Successfully aggregated exchange rates")
+
+	return aggregated, nil
+}
+
+// Объединяем курсы одной валюты, отбрасывая значения, далёкие от медианы,
+// если источников достаточно для определения выбросов
+func (a *AggregatingProvider) aggregateCurrency(currency string, results []*providerResult) (decimal.Decimal, []string, error) {
+	var values []decimal.Decimal
+	var names []string
+	for _, result := range results {
+		if rate, exists := result.rates[currency]; exists && rate.IsPositive() {
+			values = append(values, rate)
+			names = append(names, result.name)
+		}
+	}
+
+	if len(values) == 0 {
+		return decimal.Zero, nil, errRateMissing
+	}
+
+	median := utils.Median(values)
+
+	var kept []decimal.Decimal
+	var sources []string
+	for i, value := range values {
+		deviation := utils.RelativeDeviation(value, median)
+		if len(values) >= minOutlierSources && a.options.MaxDeviation > 0 && deviation > a.options.MaxDeviation {
+			a.logger.WithFields(logrus.Fields{
+				"currency":  currency,
+				"provider":  names[i],
+				"rate":      value,
+				"median":    median,
+				"deviation": deviation,
+			}).Warn("Discarding outlier rate")
+			continue
+		}
+		kept = append(kept, value)
+		sources = append(sources, names[i])
+	}
+
+	if len(kept) < a.options.MinSources {
+		a.logger.WithFields(logrus.Fields{
+			"currency":    currency,
+			"sources":     len(kept),
+			"min_sources": a.options.MinSources,
+		}).Warn("Not enough agreeing rate sources, skipping currency")
+		return decimal.Zero, nil, errInsufficientSources
+	}
+
+	sort.Strings(sources)
+
+	if a.options.Method == AggregationTrimmedMean {
+		return utils.TrimmedMean(kept, a.options.TrimRatio), sources, nil
+	}
+	return utils.Median(kept), sources, nil
+}
+
+// Возвращаем состояние всех опрашиваемых провайдеров
+func (a *AggregatingProvider) ProviderStatuses() []models.ProviderStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	statuses := make([]models.ProviderStatus, 0, len(a.breakers))
+	for _, breaker := range a.breakers {
+		status := breaker.status()
+		status.Active = a.active[status.Name]
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
@@ -0,0 +1,136 @@
+package external
+
+import (
+	"sync"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Circuit breaker одного провайдера курсов
+type circuitBreaker struct {
+	mu                  sync.Mutex
+	name                string
+	threshold           int
+	cooldown            time.Duration
+	now                 func() time.Time
+	logger              *logrus.Logger
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool
+	lastError           string
+	lastFailureAt       time.Time
+	lastSuccessAt       time.Time
+}
+
+// Создаём circuit breaker в замкнутом состоянии
+func newCircuitBreaker(name string, threshold int, cooldown time.Duration, now func() time.Time, logger *logrus.Logger) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &circuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       now,
+		logger:    logger,
+		state:     models.CircuitClosed,
+	}
+}
+
+// Проверяем, можно ли отправить запрос провайдеру
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case models.CircuitOpen:
+		// После cooldown пропускаем один пробный запрос
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = models.CircuitHalfOpen
+		b.probing = true
+		return true
+	case models.CircuitHalfOpen:
+		// Пока идёт пробный запрос, остальные пропускают провайдера
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Фиксируем ошибку провайдера и при необходимости размыкаем цепь
+func (b *circuitBreaker) recordFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.consecutiveFailures++
+	b.lastError = err.Error()
+	b.lastFailureAt = now
+	b.probing = false
+
+	if b.state == models.CircuitHalfOpen || b.consecutiveFailures >= b.threshold {
+		if b.state != models.CircuitOpen {
+			b.logger.WithFields(logrus.Fields{
+				"provider":             b.name,
+				"consecutive_failures": b.consecutiveFailures,
+				"cooldown":             b.cooldown.String(),
+			}).Warn("Rate provider circuit opened")
+		}
+		b.state = models.CircuitOpen
+		b.openedAt = now
+	}
+}
+
+// Фиксируем успешный запрос и замыкаем цепь
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != models.CircuitClosed {
+		b.logger.WithField("provider", b.name).Info("Rate provider circuit closed")
+	}
+
+	b.state = models.CircuitClosed
+	b.consecutiveFailures = 0
+	b.probing = false
+	b.lastSuccessAt = b.now()
+}
+
+// Возвращаем текущее состояние провайдера
+func (b *circuitBreaker) status() models.ProviderStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := models.ProviderStatus{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastError:           b.lastError,
+	}
+
+	if !b.lastFailureAt.IsZero() {
+		lastFailureAt := b.lastFailureAt
+		status.LastFailureAt = &lastFailureAt
+	}
+	if !b.lastSuccessAt.IsZero() {
+		lastSuccessAt := b.lastSuccessAt
+		status.LastSuccessAt = &lastSuccessAt
+	}
+	if b.state == models.CircuitOpen {
+		openUntil := b.openedAt.Add(b.cooldown)
+		status.OpenUntil = &openUntil
+	}
+
+	return status
+}
@@ -0,0 +1,90 @@
+package external
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Провайдер ежедневных курсов ЕЦБ (XML, базовая валюта EUR)
+type ECBProvider struct {
+	httpClient *http.Client
+	url        string
+	currencies *currency.Registry // Реестр валют: возвращаются курсы только включённых
+	logger     *logrus.Logger
+}
+
+// Создаём провайдер курсов ЕЦБ
+func NewECB(cfg *config.ExternalConfig, currencies *currency.Registry, logger *logrus.Logger) *ECBProvider {
+	return &ECBProvider{
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		url:        cfg.ECBURL,
+		currencies: currencies,
+		logger:     logger,
+	}
+}
+
+// Название провайдера
+func (p *ECBProvider) Name() string {
+	return ProviderECB
+}
+
+// Получаем курсы ЕЦБ и пересчитываем их относительно USD
+func (p *ECBProvider) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
+	if len(currencies) == 0 {
+		return &models.ExchangeRates{Provider: ProviderECB, Rates: make(map[string]decimal.Decimal)}, nil
+	}
+
+	symbols := symbolsWithoutUSD(enabledOnly(p.currencies, currencies, p.logger))
+	if len(symbols) == 0 {
+		return &models.ExchangeRates{Provider: ProviderECB, Rates: map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}}, nil
+	}
+
+	// ЕЦБ не поддерживает фильтрацию, поэтому всегда получаем полный список
+	body, err := fetch(p.httpClient, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.WithField("response_body", string(body)).Debug("ECB API response")
+
+	var envelope models.ECBEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// Курсы ЕЦБ указаны как количество валюты за 1 EUR
+	eurRates := map[string]decimal.Decimal{"EUR": decimal.NewFromInt(1)}
+	for _, rate := range envelope.Cube.Cube.Rates {
+		eurRates[rate.Currency] = rate.Rate
+	}
+
+	usdPerEUR, ok := eurRates["USD"]
+	if !ok || !usdPerEUR.IsPositive() {
+		return nil, fmt.Errorf("ECB response does not contain USD rate")
+	}
+
+	// Пересчитываем в курсы относительно USD только запрошенные валюты
+	rates := map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}
+	for _, currency := range symbols {
+		if eurRate, exists := eurRates[currency]; exists {
+			rates[currency] = eurRate.Div(usdPerEUR)
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"date":        envelope.Cube.Cube.Time,
+		"rates_count": len(rates),
+	}).Info("Successfully retrieved ECB exchange rates")
+
+	return &models.ExchangeRates{Provider: ProviderECB, Date: envelope.Cube.Cube.Time, Rates: rates}, nil
+}
@@ -3,86 +3,68 @@ package external
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 
 	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
 	"go_plata_task_v2/internal/models"
 
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
-// Клиент для работы с внешним API
+// Клиент для работы с внешним API в формате fxratesapi
 type Client struct {
-	httpClient          *http.Client
-	baseURL             string
-	apiKey              string
-	supportedCurrencies []string
-	logger              *logrus.Logger
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	currencies *currency.Registry // Реестр валют: у API запрашиваются только включённые
+	logger     *logrus.Logger
 }
 
 // Создаём новый клиент для внешнего API
-func New(cfg *config.ExternalConfig, supportedCurrencies []string, logger *logrus.Logger) *Client {
+func New(cfg *config.ExternalConfig, currencies *currency.Registry, logger *logrus.Logger) *Client {
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
-		baseURL:             cfg.BaseURL,
-		apiKey:              cfg.APIKey,
-		supportedCurrencies: supportedCurrencies,
-		logger:              logger,
+		baseURL:    cfg.BaseURL,
+		apiKey:     cfg.APIKey,
+		currencies: currencies,
+		logger:     logger,
 	}
 }
 
+// Название провайдера
+func (c *Client) Name() string {
+	return ProviderFXRatesAPI
+}
+
 // Получаем курсы всех валют относительно USD одним запросом
-func (c *Client) GetMultipleExchangeRates(currencies []string) (map[string]float64, error) {
+func (c *Client) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
 	if len(currencies) == 0 {
-		return make(map[string]float64), nil
-	}
-
-	// Убираем дубликаты, исключаем USD так как он уже указан как base
-	uniqueCurrencies := make(map[string]bool)
-	for _, currency := range currencies {
-		if currency != "USD" { // Исключаем USD из symbols, так как base=USD
-			uniqueCurrencies[currency] = true
-		}
+		return &models.ExchangeRates{Provider: ProviderFXRatesAPI, Rates: make(map[string]decimal.Decimal)}, nil
 	}
 
-	// Формируем список символов для запроса
-	var symbols []string
-	for currency := range uniqueCurrencies {
-		symbols = append(symbols, currency)
+	// Убираем дубликаты и отключённые в реестре валюты, исключаем USD так как он уже указан как base
+	symbols := symbolsWithoutUSD(enabledOnly(c.currencies, currencies, c.logger))
+	if len(symbols) == 0 {
+		return &models.ExchangeRates{Provider: ProviderFXRatesAPI, Rates: map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}}, nil
 	}
 
 	// Формируем URL для batch запроса
 	url := fmt.Sprintf("%s/latest?base=USD&symbols=%s", c.baseURL, strings.Join(symbols, ","))
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
 	// Добавляем API ключ если он есть
+	headers := make(map[string]string)
 	if c.apiKey != "" {
-		req.Header.Set("apikey", c.apiKey)
+		headers["apikey"] = c.apiKey
 	}
 
-	req.Header.Set("User-Agent", "Currency-Quote-Service/1.0")
-
-	resp, err := c.httpClient.Do(req)
+	body, err := fetch(c.httpClient, url, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	c.logger.WithField("response_body", string(body)).Debug("External API batch response")
@@ -97,12 +79,32 @@ func (c *Client) GetMultipleExchangeRates(currencies []string) (map[string]float
 	}
 
 	// Добавляем USD в результат (курс USD к самому себе = 1.0)
-	apiResp.Rates["USD"] = 1.0
+	if apiResp.Rates == nil {
+		apiResp.Rates = make(map[string]decimal.Decimal)
+	}
+	apiResp.Rates["USD"] = decimal.NewFromInt(1)
 
 	c.logger.WithFields(logrus.Fields{
 		"currencies":  symbols,
 		"rates_count": len(apiResp.Rates),
 	}).Info("Successfully retrieved batch exchange rates")
 
-	return apiResp.Rates, nil
+	return &models.ExchangeRates{Provider: ProviderFXRatesAPI, Date: apiResp.Date, Rates: apiResp.Rates}, nil
+}
+
+// Оставляем валюты, включённые в реестре. Без реестра запрашиваются все валюты
+func enabledOnly(registry *currency.Registry, currencies []string, logger *logrus.Logger) []string {
+	if registry == nil {
+		return currencies
+	}
+
+	var enabled []string
+	for _, code := range currencies {
+		if registry.IsEnabled(code) {
+			enabled = append(enabled, code)
+		} else {
+			logger.WithField("currency", code).Debug("Skipping currency disabled in registry")
+		}
+	}
+	return enabled
 }
@@ -0,0 +1,443 @@
+package external
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const ecbDailyXML = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-09-26">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="MXN" rate="25.0"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.ExternalConfig
+		expectedName  string
+		expectedChain []string
+		wantErr       bool
+	}{
+		{
+			name:          "Single provider",
+			cfg:           config.ExternalConfig{Providers: []string{ProviderFXRatesAPI}},
+			expectedName:  ProviderFailover,
+			expectedChain: []string{ProviderFXRatesAPI},
+		},
+		{
+			name:          "Ordered chain",
+			cfg:           config.ExternalConfig{Providers: []string{ProviderECB, " openexchangerates", ProviderFXRatesAPI}, Mode: ModeFailover},
+			expectedName:  ProviderFailover,
+			expectedChain: []string{ProviderECB, ProviderOpenExchangeRates, ProviderFXRatesAPI},
+		},
+		{
+			name:          "Aggregate mode",
+			cfg:           config.ExternalConfig{Providers: []string{ProviderECB, ProviderFXRatesAPI}, Mode: ModeAggregate, AggregationMethod: AggregationMedian},
+			expectedName:  ProviderAggregate,
+			expectedChain: []string{ProviderECB, ProviderFXRatesAPI},
+		},
+		{
+			name:    "Unknown aggregation method",
+			cfg:     config.ExternalConfig{Providers: []string{ProviderECB}, Mode: ModeAggregate, AggregationMethod: "mean"},
+			wantErr: true,
+		},
+		{name: "Unknown mode", cfg: config.ExternalConfig{Providers: []string{ProviderECB}, Mode: "random"}, wantErr: true},
+		{name: "Unknown provider", cfg: config.ExternalConfig{Providers: []string{ProviderECB, "unknown"}}, wantErr: true},
+		{name: "Empty list", cfg: config.ExternalConfig{Providers: []string{""}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(&tt.cfg, nil, logrus.New())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedName, provider.Name())
+
+			var chain []string
+			for _, status := range provider.ProviderStatuses() {
+				chain = append(chain, status.Name)
+			}
+			assert.Equal(t, tt.expectedChain, chain)
+		})
+	}
+}
+
+func TestClient_GetMultipleExchangeRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		assert.Equal(t, "secret", r.Header.Get("apikey"))
+		w.Write([]byte(`{"success":true,"date":"2025-09-26","rates":{"EUR":0.8,"MXN":20.0}}`))
+	}))
+	defer server.Close()
+
+	client := New(&config.ExternalConfig{BaseURL: server.URL, APIKey: "secret", Timeout: time.Second}, nil, logrus.New())
+
+	rates, err := client.GetMultipleExchangeRates([]string{"USD", "EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8", "MXN": "20"}, rateStrings(rates.Rates))
+	assert.Equal(t, "2025-09-26", rates.Date)
+}
+
+func TestClient_SkipsCurrenciesDisabledInRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "EUR", r.URL.Query().Get("symbols"))
+		w.Write([]byte(`{"success":true,"date":"2025-09-26","rates":{"EUR":0.8}}`))
+	}))
+	defer server.Close()
+
+	client := New(&config.ExternalConfig{BaseURL: server.URL, Timeout: time.Second}, currency.NewStatic("USD", "EUR"), logrus.New())
+
+	rates, err := client.GetMultipleExchangeRates([]string{"USD", "EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8"}, rateStrings(rates.Rates))
+}
+
+func TestECBProvider_GetMultipleExchangeRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ecbDailyXML))
+	}))
+	defer server.Close()
+
+	provider := NewECB(&config.ExternalConfig{ECBURL: server.URL, Timeout: time.Second}, nil, logrus.New())
+
+	rates, err := provider.GetMultipleExchangeRates([]string{"USD", "EUR", "MXN"})
+	assert.NoError(t, err)
+
+	// 1 USD = 1/1.25 EUR = 0.8 EUR, 1 USD = 25/1.25 MXN = 20 MXN
+	assert.Equal(t, ProviderECB, rates.Provider)
+	assert.Equal(t, "2025-09-26", rates.Date)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8", "MXN": "20"}, rateStrings(rates.Rates))
+}
+
+func TestECBProvider_MissingUSD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<Envelope><Cube><Cube time="2025-09-26"><Cube currency="MXN" rate="25.0"/></Cube></Cube></Envelope>`))
+	}))
+	defer server.Close()
+
+	provider := NewECB(&config.ExternalConfig{ECBURL: server.URL, Timeout: time.Second}, nil, logrus.New())
+
+	_, err := provider.GetMultipleExchangeRates([]string{"EUR", "MXN"})
+	assert.Error(t, err)
+}
+
+func TestECBProvider_SkipsCurrenciesDisabledInRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ecbDailyXML))
+	}))
+	defer server.Close()
+
+	provider := NewECB(&config.ExternalConfig{ECBURL: server.URL, Timeout: time.Second}, currency.NewStatic("USD", "EUR"), logrus.New())
+
+	rates, err := provider.GetMultipleExchangeRates([]string{"USD", "EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8"}, rateStrings(rates.Rates))
+}
+
+func TestOpenExchangeRatesProvider_GetMultipleExchangeRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest.json", r.URL.Path)
+		assert.Equal(t, "app-id", r.URL.Query().Get("app_id"))
+		w.Write([]byte(`{"timestamp":1758844800,"base":"USD","rates":{"EUR":0.8,"MXN":20.0}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenExchangeRates(&config.ExternalConfig{
+		OpenExchangeRatesURL:   server.URL,
+		OpenExchangeRatesAppID: "app-id",
+		Timeout:                time.Second,
+	}, nil, logrus.New())
+
+	rates, err := provider.GetMultipleExchangeRates([]string{"EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8", "MXN": "20"}, rateStrings(rates.Rates))
+	assert.Equal(t, "2025-09-26T00:00:00Z", rates.Date)
+}
+
+func TestOpenExchangeRatesProvider_SkipsCurrenciesDisabledInRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "EUR", r.URL.Query().Get("symbols"))
+		// API вернул и незапрошенную валюту
+		w.Write([]byte(`{"timestamp":1758844800,"base":"USD","rates":{"EUR":0.8,"MXN":20.0}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenExchangeRates(&config.ExternalConfig{OpenExchangeRatesURL: server.URL, Timeout: time.Second}, currency.NewStatic("USD", "EUR"), logrus.New())
+
+	rates, err := provider.GetMultipleExchangeRates([]string{"EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "1", "EUR": "0.8"}, rateStrings(rates.Rates))
+}
+
+func TestOpenExchangeRatesProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "HTTP error", status: http.StatusUnauthorized, body: `{"error":true,"status":401,"description":"Invalid App ID"}`},
+		{name: "Error flag", status: http.StatusOK, body: `{"error":true,"description":"Not allowed"}`},
+		{name: "Unexpected base", status: http.StatusOK, body: `{"base":"EUR","rates":{"USD":1.1}}`},
+		{name: "Invalid JSON", status: http.StatusOK, body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewOpenExchangeRates(&config.ExternalConfig{OpenExchangeRatesURL: server.URL, Timeout: time.Second}, nil, logrus.New())
+
+			_, err := provider.GetMultipleExchangeRates([]string{"EUR"})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// Провайдер для тестов цепочки, возвращающий заданные курсы или ошибку
+type stubProvider struct {
+	name  string
+	rates map[string]float64
+	date  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.rates != nil {
+		rates := make(map[string]decimal.Decimal, len(p.rates))
+		for currency, rate := range p.rates {
+			rates[currency] = decimal.NewFromFloat(rate)
+		}
+		return &models.ExchangeRates{Provider: p.name, Date: p.date, Rates: rates}, nil
+	}
+	return &models.ExchangeRates{Provider: p.name, Rates: map[string]decimal.Decimal{"USD": decimal.NewFromInt(1), "EUR": decimal.RequireFromString("0.8")}}, nil
+}
+
+func TestFailoverProvider_FallsThroughOnError(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: assert.AnError}
+	secondary := &stubProvider{name: "secondary"}
+
+	chain := NewFailover([]RateProvider{primary, secondary}, 3, time.Minute, logrus.New())
+
+	rates, err := chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.8", rates.Rates["EUR"].String())
+	assert.Equal(t, []string{"secondary"}, rates.SourcesFor("EUR"))
+
+	statuses := chain.ProviderStatuses()
+	assert.Equal(t, 1, statuses[0].ConsecutiveFailures)
+	assert.Equal(t, models.CircuitClosed, statuses[0].State)
+	assert.False(t, statuses[0].Active)
+	assert.True(t, statuses[1].Active)
+	assert.NotNil(t, statuses[1].LastSuccessAt)
+}
+
+func TestFailoverProvider_CircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 9, 28, 12, 0, 0, 0, time.UTC)
+	primary := &stubProvider{name: "primary", err: assert.AnError}
+	secondary := &stubProvider{name: "secondary"}
+
+	chain := NewFailover([]RateProvider{primary, secondary}, 2, time.Minute, logrus.New())
+	chain.now = func() time.Time { return now }
+
+	// Две ошибки подряд размыкают цепь основного провайдера
+	for i := 0; i < 2; i++ {
+		_, err := chain.GetMultipleExchangeRates([]string{"EUR"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, models.CircuitOpen, chain.ProviderStatuses()[0].State)
+	assert.Equal(t, now.Add(time.Minute), *chain.ProviderStatuses()[0].OpenUntil)
+
+	// Во время cooldown основной провайдер не вызывается
+	_, err := chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, primary.calls)
+
+	// После cooldown пробный запрос завершается ошибкой, и цепь снова размыкается
+	now = now.Add(time.Minute)
+	_, err = chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, primary.calls)
+	assert.Equal(t, models.CircuitOpen, chain.ProviderStatuses()[0].State)
+
+	// Успешный пробный запрос замыкает цепь
+	now = now.Add(time.Minute)
+	primary.err = nil
+	_, err = chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.NoError(t, err)
+
+	status := chain.ProviderStatuses()[0]
+	assert.Equal(t, models.CircuitClosed, status.State)
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+	assert.True(t, status.Active)
+}
+
+func TestFailoverProvider_AllFailed(t *testing.T) {
+	chain := NewFailover([]RateProvider{
+		&stubProvider{name: "primary", err: assert.AnError},
+		&stubProvider{name: "secondary", err: assert.AnError},
+	}, 1, time.Minute, logrus.New())
+
+	_, err := chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.Contains(t, err.Error(), "all rate providers failed")
+
+	// Теперь цепи обоих провайдеров разомкнуты
+	_, err = chain.GetMultipleExchangeRates([]string{"EUR"})
+	assert.Contains(t, err.Error(), "circuit open")
+}
+
+func TestAggregatingProvider_DiscardsOutliers(t *testing.T) {
+	providers := []RateProvider{
+		&stubProvider{name: "a", date: "2025-09-26", rates: map[string]float64{"USD": 1.0, "EUR": 0.80, "MXN": 18.4}},
+		&stubProvider{name: "b", date: "2025-09-25", rates: map[string]float64{"USD": 1.0, "EUR": 0.81, "MXN": 18.6}},
+		&stubProvider{name: "c", rates: map[string]float64{"USD": 1.0, "EUR": 0.82, "MXN": 25.0}},
+	}
+
+	aggregator := NewAggregating(providers, AggregationOptions{
+		Method:       AggregationMedian,
+		MaxDeviation: 0.05,
+		MinSources:   2,
+	}, 3, time.Minute, logrus.New())
+
+	rates, err := aggregator.GetMultipleExchangeRates([]string{"EUR", "MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderAggregate, rates.Provider)
+	assert.Equal(t, "2025-09-25", rates.Date)
+
+	// Все три курса EUR согласованы
+	assert.Equal(t, "0.81", rates.Rates["EUR"].String())
+	assert.Equal(t, []string{"a", "b", "c"}, rates.Sources["EUR"])
+
+	// Курс MXN провайдера c отброшен как выброс
+	assert.Equal(t, "18.5", rates.Rates["MXN"].String())
+	assert.Equal(t, []string{"a", "b"}, rates.Sources["MXN"])
+	assert.Equal(t, []string{"a", "b", "c"}, rates.SourcesFor("EUR", "MXN"))
+}
+
+func TestAggregatingProvider_TwoSourcesKeepBoth(t *testing.T) {
+	providers := []RateProvider{
+		&stubProvider{name: "a", rates: map[string]float64{"MXN": 18.0}},
+		&stubProvider{name: "b", rates: map[string]float64{"MXN": 25.0}},
+	}
+
+	aggregator := NewAggregating(providers, AggregationOptions{
+		Method:       AggregationMedian,
+		MaxDeviation: 0.05,
+		MinSources:   1,
+	}, 3, time.Minute, logrus.New())
+
+	// По двум источникам выброс не определить, валюта остаётся в снимке
+	rates, err := aggregator.GetMultipleExchangeRates([]string{"MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, "21.5", rates.Rates["MXN"].String())
+	assert.Equal(t, []string{"a", "b"}, rates.Sources["MXN"])
+}
+
+func TestAggregatingProvider_EarliestDateAcrossFormats(t *testing.T) {
+	providers := []RateProvider{
+		&stubProvider{name: "ecb", date: "2025-09-25", rates: map[string]float64{"MXN": 18.5}},
+		&stubProvider{name: "oxr", date: "2025-09-25T02:00:00+05:00", rates: map[string]float64{"MXN": 18.5}},
+		&stubProvider{name: "broken", date: "yesterday", rates: map[string]float64{"MXN": 18.5}},
+	}
+
+	aggregator := NewAggregating(providers, AggregationOptions{Method: AggregationMedian}, 3, time.Minute, logrus.New())
+
+	// Курсы oxr опубликованы 24 сентября в 21:00 UTC, раньше курсов ECB,
+	// хотя при сравнении строк "2025-09-25" меньше. Нераспознанная дата не учитывается
+	rates, err := aggregator.GetMultipleExchangeRates([]string{"MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-09-25T02:00:00+05:00", rates.Date)
+}
+
+func TestAggregatingProvider_TrimmedMean(t *testing.T) {
+	providers := []RateProvider{
+		&stubProvider{name: "a", rates: map[string]float64{"MXN": 18.0}},
+		&stubProvider{name: "b", rates: map[string]float64{"MXN": 18.5}},
+		&stubProvider{name: "c", rates: map[string]float64{"MXN": 18.6}},
+		&stubProvider{name: "d", rates: map[string]float64{"MXN": 19.0}},
+	}
+
+	aggregator := NewAggregating(providers, AggregationOptions{
+		Method:    AggregationTrimmedMean,
+		TrimRatio: 0.25,
+	}, 3, time.Minute, logrus.New())
+
+	rates, err := aggregator.GetMultipleExchangeRates([]string{"MXN"})
+	assert.NoError(t, err)
+	assert.Equal(t, "18.55", rates.Rates["MXN"].String())
+}
+
+func TestAggregatingProvider_NotEnoughSources(t *testing.T) {
+	providers := []RateProvider{
+		&stubProvider{name: "a", rates: map[string]float64{"MXN": 18.5}},
+		&stubProvider{name: "b", err: assert.AnError},
+	}
+
+	aggregator := NewAggregating(providers, AggregationOptions{
+		Method:     AggregationMedian,
+		MinSources: 2,
+	}, 3, time.Minute, logrus.New())
+
+	rates, err := aggregator.GetMultipleExchangeRates([]string{"MXN"})
+	assert.NoError(t, err)
+
+	_, exists := rates.Rates["MXN"]
+	assert.False(t, exists)
+	// Воркер отличает нехватку источников от отсутствия курса и повторяет такие запросы
+	assert.True(t, rates.HasInsufficientSources("EUR", "MXN"))
+	assert.False(t, rates.HasInsufficientSources("EUR"))
+
+	statuses := aggregator.ProviderStatuses()
+	assert.Equal(t, 1, statuses[1].ConsecutiveFailures)
+}
+
+func TestAggregatingProvider_AllFailed(t *testing.T) {
+	aggregator := NewAggregating([]RateProvider{
+		&stubProvider{name: "a", err: assert.AnError},
+	}, AggregationOptions{Method: AggregationMedian}, 3, time.Minute, logrus.New())
+
+	_, err := aggregator.GetMultipleExchangeRates([]string{"MXN"})
+	assert.Error(t, err)
+}
+
+// Переводим курсы в строки для сравнения без учёта внутреннего представления decimal
+func rateStrings(rates map[string]decimal.Decimal) map[string]string {
+	result := make(map[string]string, len(rates))
+	for currency, rate := range rates {
+		result[currency] = rate.String()
+	}
+	return result
+}
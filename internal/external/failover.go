@@ -0,0 +1,124 @@
+package external
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Название цепочки провайдеров
+const ProviderFailover = "failover"
+
+// HealthReporter отдаёт состояние провайдеров курсов для диагностики
+type HealthReporter interface {
+	ProviderStatuses() []models.ProviderStatus
+}
+
+// MonitoredProvider объединяет источник курсов и диагностику его провайдеров
+type MonitoredProvider interface {
+	RateProvider
+	HealthReporter
+}
+
+// Цепочка провайдеров: при ошибке запрос переходит к следующему провайдеру,
+// а провайдеры с серией ошибок временно исключаются (circuit breaker)
+type FailoverProvider struct {
+	mu        sync.Mutex
+	providers []RateProvider
+	breakers  []*circuitBreaker
+	active    string
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// Убеждаемся, что цепочка реализует MonitoredProvider
+var _ MonitoredProvider = (*FailoverProvider)(nil)
+
+// Создаём цепочку провайдеров в порядке приоритета
+func NewFailover(providers []RateProvider, threshold int, cooldown time.Duration, logger *logrus.Logger) *FailoverProvider {
+	chain := &FailoverProvider{
+		providers: providers,
+		logger:    logger,
+		now:       time.Now,
+	}
+
+	for _, provider := range providers {
+		chain.breakers = append(chain.breakers,
+			newCircuitBreaker(provider.Name(), threshold, cooldown, func() time.Time { return chain.now() }, logger))
+	}
+
+	return chain
+}
+
+// Название провайдера
+func (f *FailoverProvider) Name() string {
+	return ProviderFailover
+}
+
+// Получаем курсы у первого доступного провайдера цепочки
+func (f *FailoverProvider) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
+	var errs []string
+
+	for i, provider := range f.providers {
+		breaker := f.breakers[i]
+		name := provider.Name()
+
+		if !breaker.allow() {
+			f.logger.WithField("provider", name).Debug("Skipping rate provider with open circuit")
+			continue
+		}
+
+		rates, err := provider.GetMultipleExchangeRates(currencies)
+		if err != nil {
+			breaker.recordFailure(err)
+			f.logger.WithError(err).WithField("provider", name).Warn("Rate provider failed, trying next one")
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		breaker.recordSuccess()
+		f.setActive(name)
+		return rates, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("all rate providers are unavailable (circuit open)")
+	}
+
+	return nil, fmt.Errorf("all rate providers failed: %s", strings.Join(errs, "; "))
+}
+
+// Возвращаем состояние всех провайдеров цепочки
+func (f *FailoverProvider) ProviderStatuses() []models.ProviderStatus {
+	f.mu.Lock()
+	active := f.active
+	f.mu.Unlock()
+
+	statuses := make([]models.ProviderStatus, 0, len(f.breakers))
+	for _, breaker := range f.breakers {
+		status := breaker.status()
+		status.Active = status.Name == active
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Запоминаем провайдера, который обслужил последний запрос
+func (f *FailoverProvider) setActive(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.active != name {
+		f.logger.WithFields(logrus.Fields{
+			"provider": name,
+			"previous": f.active,
+		}).Info("Active rate provider changed")
+	}
+	f.active = name
+}
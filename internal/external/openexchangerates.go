@@ -0,0 +1,100 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Провайдер курсов в формате openexchangerates (JSON, базовая валюта USD)
+type OpenExchangeRatesProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	appID      string
+	currencies *currency.Registry // Реестр валют: у API запрашиваются только включённые
+	logger     *logrus.Logger
+}
+
+// Создаём провайдер курсов openexchangerates
+func NewOpenExchangeRates(cfg *config.ExternalConfig, currencies *currency.Registry, logger *logrus.Logger) *OpenExchangeRatesProvider {
+	return &OpenExchangeRatesProvider{
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		baseURL:    cfg.OpenExchangeRatesURL,
+		appID:      cfg.OpenExchangeRatesAppID,
+		currencies: currencies,
+		logger:     logger,
+	}
+}
+
+// Название провайдера
+func (p *OpenExchangeRatesProvider) Name() string {
+	return ProviderOpenExchangeRates
+}
+
+// Получаем курсы всех валют относительно USD одним запросом
+func (p *OpenExchangeRatesProvider) GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error) {
+	if len(currencies) == 0 {
+		return &models.ExchangeRates{Provider: ProviderOpenExchangeRates, Rates: make(map[string]decimal.Decimal)}, nil
+	}
+
+	symbols := symbolsWithoutUSD(enabledOnly(p.currencies, currencies, p.logger))
+	if len(symbols) == 0 {
+		return &models.ExchangeRates{Provider: ProviderOpenExchangeRates, Rates: map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}}, nil
+	}
+
+	params := url.Values{}
+	params.Set("app_id", p.appID)
+	params.Set("base", "USD")
+	params.Set("symbols", strings.Join(symbols, ","))
+
+	body, err := fetch(p.httpClient, p.baseURL+"/latest.json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp models.OpenExchangeRatesResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if apiResp.Error {
+		return nil, fmt.Errorf("API returned error: %s", apiResp.Description)
+	}
+
+	if apiResp.Base != "" && apiResp.Base != "USD" {
+		return nil, fmt.Errorf("API returned unexpected base currency %s", apiResp.Base)
+	}
+
+	// API может вернуть больше валют, чем запрошено: оставляем только запрошенные
+	rates := map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}
+	for _, currency := range symbols {
+		if rate, exists := apiResp.Rates[currency]; exists {
+			rates[currency] = rate
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"currencies":  symbols,
+		"rates_count": len(rates),
+	}).Info("Successfully retrieved openexchangerates exchange rates")
+
+	// Вместо даты openexchangerates возвращает unix-время публикации курсов
+	var date string
+	if apiResp.Timestamp > 0 {
+		date = time.Unix(apiResp.Timestamp, 0).UTC().Format(time.RFC3339)
+	}
+
+	return &models.ExchangeRates{Provider: ProviderOpenExchangeRates, Date: date, Rates: rates}, nil
+}
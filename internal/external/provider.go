@@ -0,0 +1,140 @@
+package external
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Названия поддерживаемых провайдеров курсов
+const (
+	ProviderFXRatesAPI        = "fxratesapi"
+	ProviderECB               = "ecb"
+	ProviderOpenExchangeRates = "openexchangerates"
+)
+
+// Режимы работы с несколькими провайдерами
+const (
+	ModeFailover  = "failover"
+	ModeAggregate = "aggregate"
+)
+
+// RateProvider определяет источник курсов валют относительно USD
+type RateProvider interface {
+	// Name возвращает название провайдера для логов и диагностики
+	Name() string
+	// GetMultipleExchangeRates возвращает курсы запрошенных валют относительно USD (USD всегда равен 1.0)
+	GetMultipleExchangeRates(currencies []string) (*models.ExchangeRates, error)
+}
+
+// Убеждаемся, что адаптеры реализуют RateProvider
+var (
+	_ RateProvider = (*Client)(nil)
+	_ RateProvider = (*ECBProvider)(nil)
+	_ RateProvider = (*OpenExchangeRatesProvider)(nil)
+)
+
+// Создаём источник курсов из провайдеров, заданных в конфигурации:
+// цепочку failover или агрегатор в зависимости от режима
+func NewProvider(cfg *config.ExternalConfig, currencies *currency.Registry, logger *logrus.Logger) (MonitoredProvider, error) {
+	var providers []RateProvider
+	for _, name := range cfg.Providers {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		provider, err := newAdapter(name, cfg, currencies, logger)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no rate providers configured")
+	}
+
+	switch cfg.Mode {
+	case ModeFailover, "":
+		return NewFailover(providers, cfg.CircuitBreakerThreshold, cfg.CircuitBreakerCooldown, logger), nil
+	case ModeAggregate:
+		if cfg.AggregationMethod != AggregationMedian && cfg.AggregationMethod != AggregationTrimmedMean {
+			return nil, fmt.Errorf("unknown aggregation method %q", cfg.AggregationMethod)
+		}
+
+		options := AggregationOptions{
+			Method:       cfg.AggregationMethod,
+			TrimRatio:    cfg.AggregationTrimRatio,
+			MaxDeviation: cfg.AggregationMaxDeviation,
+			MinSources:   cfg.AggregationMinSources,
+		}
+		return NewAggregating(providers, options, cfg.CircuitBreakerThreshold, cfg.CircuitBreakerCooldown, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown rate provider mode %q", cfg.Mode)
+	}
+}
+
+// Создаём адаптер провайдера по названию
+func newAdapter(name string, cfg *config.ExternalConfig, currencies *currency.Registry, logger *logrus.Logger) (RateProvider, error) {
+	switch name {
+	case ProviderFXRatesAPI:
+		return New(cfg, currencies, logger), nil
+	case ProviderECB:
+		return NewECB(cfg, currencies, logger), nil
+	case ProviderOpenExchangeRates:
+		return NewOpenExchangeRates(cfg, currencies, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown rate provider %q", name)
+	}
+}
+
+// Выполняем GET запрос к провайдеру и возвращаем тело ответа
+func fetch(httpClient *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("User-Agent", "Currency-Quote-Service/1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return body, nil
+}
+
+// Убираем дубликаты и USD из списка запрашиваемых валют
+func symbolsWithoutUSD(currencies []string) []string {
+	unique := make(map[string]bool)
+	var symbols []string
+	for _, currency := range currencies {
+		if currency != "USD" && !unique[currency] {
+			unique[currency] = true
+			symbols = append(symbols, currency)
+		}
+	}
+	return symbols
+}
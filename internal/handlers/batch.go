@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Максимальное количество валютных пар в пакетном запросе
+const maxBatchPairs = 100
+
+// @Summary Обновить котировки нескольких валютных пар
+// @Description Создает запросы на обновление котировок для списка пар (не больше 100). Для каждой пары возвращается ID запроса
+// @Description или ошибка; ошибка одной пары не влияет на остальные. Повторяющиеся пары обрабатываются один раз.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param request body models.BatchUpdateQuoteRequest true "Список валютных пар"
+// @Success 200 {object} models.BatchUpdateQuoteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /quotes/update/batch [post]
+func (h *Handler) BatchUpdateQuotes(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchUpdateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	if len(req.Pairs) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "pairs is required")
+		return
+	}
+	if len(req.Pairs) > maxBatchPairs {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", fmt.Sprintf("At most %d pairs are allowed per request", maxBatchPairs))
+		return
+	}
+
+	response := models.BatchUpdateQuoteResponse{Results: make([]models.BatchUpdateQuoteResult, 0, len(req.Pairs))}
+	seen := make(map[string]bool)
+	for _, pair := range req.Pairs {
+		result := models.BatchUpdateQuoteResult{
+			Pair: strings.ToUpper(strings.TrimSpace(pair.From)) + "/" + strings.ToUpper(strings.TrimSpace(pair.To)),
+		}
+
+		from, to, err := h.normalizeCurrencyPair(pair.From, pair.To)
+		if err == nil && from == to {
+			err = fmt.Errorf("From and To currencies must be different")
+		}
+		if err != nil {
+			result.Error = &models.ErrorResponse{Error: "Validation error", Message: err.Error()}
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		if seen[result.Pair] {
+			continue
+		}
+		seen[result.Pair] = true
+
+		// Создаем или получаем существующий pending запрос (идемпотентность)
+		quoteRequest, err := h.db.CreateOrGetPendingQuoteRequest(from, to)
+		if err != nil {
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"from": from,
+				"to":   to,
+			}).Error("Failed to create or get quote request")
+			result.Error = &models.ErrorResponse{Error: "Internal error", Message: "Failed to create quote request"}
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		update := quoteRequest.UpdateResponse()
+		result.Request = &update
+		response.Results = append(response.Results, result)
+	}
+
+	h.logger.WithField("count", len(response.Results)).Info("Batch quote update requests created or retrieved")
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// @Summary Получить последние котировки нескольких валютных пар
+// @Description Возвращает последние котировки для списка пар (не больше 100) одним запросом к базе данных.
+// @Description Ошибки (неверная пара, нет котировки, устаревшая котировка при on_stale=reject) возвращаются для каждой пары отдельно.
+// @Tags quotes
+// @Produce json
+// @Param pairs query string true "Список пар через запятую, например EUR/MXN,USD/EUR"
+// @Param max_age query string false "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации"
+// @Param on_stale query string false "Поведение для устаревшей котировки: refresh (по умолчанию) или reject"
+// @Param tier query string false "Уровень клиента для наценки в bid и ask"
+// @Success 200 {object} models.BatchLatestQuotesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /quotes/latest/batch [get]
+func (h *Handler) GetLatestQuotesBatch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	value := strings.TrimSpace(query.Get("pairs"))
+	if value == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "pairs parameter is required")
+		return
+	}
+
+	rawPairs := strings.Split(value, ",")
+	if len(rawPairs) > maxBatchPairs {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", fmt.Sprintf("At most %d pairs are allowed per request", maxBatchPairs))
+		return
+	}
+
+	// Параметры актуальности общие для всех пар, проверяем их один раз
+	if _, _, err := h.parseStaleness(query, "", ""); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	tier, markupBps, err := h.parseTier(query)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	// Проверяем пары, ошибки записываем в результат конкретной пары
+	results := make([]models.BatchQuoteResult, 0, len(rawPairs))
+	pairs := make([]models.CurrencyPair, 0, len(rawPairs))
+	seen := make(map[string]bool)
+	for _, raw := range rawPairs {
+		pair, err := h.parseCurrencyPair(raw)
+		if err != nil {
+			results = append(results, models.BatchQuoteResult{
+				Pair:  strings.ToUpper(strings.TrimSpace(raw)),
+				Error: &models.ErrorResponse{Error: "Validation error", Message: err.Error()},
+			})
+			continue
+		}
+
+		if seen[pair.String()] {
+			continue
+		}
+		seen[pair.String()] = true
+		pairs = append(pairs, pair)
+		results = append(results, models.BatchQuoteResult{Pair: pair.String()})
+	}
+
+	quotesByPair := make(map[string]*models.Quote)
+	if len(pairs) > 0 {
+		quotes, err := h.db.GetQuotes(pairs)
+		if err != nil {
+			h.logger.WithError(err).WithField("count", len(pairs)).Error("Failed to get latest quotes")
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get quotes")
+			return
+		}
+		for _, quote := range quotes {
+			quotesByPair[models.CurrencyPair{From: quote.From, To: quote.To}.String()] = quote
+		}
+	}
+
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+
+		quote, exists := quotesByPair[results[i].Pair]
+		if !exists {
+			results[i].Error = &models.ErrorResponse{Error: "Not found", Message: "Quote not found for currency pair: " + results[i].Pair}
+			continue
+		}
+
+		response := quote.Response()
+		if err := h.applyPricing(&response, tier, markupBps); err != nil {
+			h.logger.WithError(err).Error("Failed to price latest quote")
+			results[i].Error = &models.ErrorResponse{Error: "Internal error", Message: "Failed to price quote"}
+			continue
+		}
+
+		maxAge, stalePolicy, _ := h.parseStaleness(query, quote.From, quote.To)
+		h.checkFreshness(&response, maxAge, stalePolicy)
+		if response.Freshness != nil && response.Freshness.Stale && stalePolicy == config.StalePolicyReject {
+			results[i].Error = &models.ErrorResponse{
+				Error:   "Stale quote",
+				Message: fmt.Sprintf("Quote for %s is older than %s, refresh has been requested", results[i].Pair, maxAge),
+			}
+			continue
+		}
+
+		results[i].Quote = &response
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, models.BatchLatestQuotesResponse{Results: results})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Пересчитать сумму в другую валюту
+// @Description Пересчитывает сумму по последней котировке пары и округляет результат до минимальных единиц валюты to по ISO 4217.
+// @Description Количество знаков после запятой в amount не может превышать минимальные единицы валюты from.
+// @Description Устаревшая котировка обрабатывается так же, как в /quotes/latest.
+// @Tags quotes
+// @Produce json
+// @Param from query string true "Исходная валюта (например, EUR)"
+// @Param to query string true "Целевая валюта (например, MXN)"
+// @Param amount query string true "Сумма в исходной валюте, например 1234.56"
+// @Param rounding query string false "Режим округления: half-even (по умолчанию), half-up или down"
+// @Param max_age query string false "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации"
+// @Param on_stale query string false "Поведение для устаревшей котировки: refresh (по умолчанию) или reject"
+// @Success 200 {object} models.ConversionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.StaleQuoteErrorResponse "Котировка устарела, обновление поставлено в очередь"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert [get]
+func (h *Handler) Convert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := h.normalizeCurrencyPair(query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	if query.Get("amount") == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Amount is required")
+		return
+	}
+	amount, err := utils.ParseAmount(query.Get("amount"), h.currencyExponent(from))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	rounding, err := utils.ParseRoundingMode(query.Get("rounding"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	maxAge, stalePolicy, err := h.parseStaleness(query, from, to)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	quote, err := h.db.GetQuote(from, to)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+		}).Error("Failed to get quote for conversion")
+		h.writeLookupError(w, err, "Quote not found for currency pair: "+from+"/"+to)
+		return
+	}
+
+	// Пересчёт по устаревшей котировке подчиняется той же политике, что и /quotes/latest
+	quoteResponse := quote.Response()
+	refresh := h.checkFreshness(&quoteResponse, maxAge, stalePolicy)
+	if quoteResponse.Freshness != nil && quoteResponse.Freshness.Stale && stalePolicy == config.StalePolicyReject {
+		if refresh != nil {
+			h.setRetryAfter(w, refresh)
+		}
+		h.writeJSONResponse(w, http.StatusServiceUnavailable, models.StaleQuoteErrorResponse{
+			Error:   "Stale quote",
+			Message: fmt.Sprintf("Quote for %s/%s is older than %s, refresh has been requested", from, to, maxAge),
+			Quote:   quoteResponse,
+		})
+		return
+	}
+
+	minorUnits := h.currencyExponent(to)
+	converted, err := utils.ConvertAmount(amount, quote.Rate, minorUnits, rounding)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+			"rate": quote.Rate,
+		}).Error("Failed to convert amount")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to convert amount")
+		return
+	}
+
+	response := models.ConversionResponse{
+		From:            from,
+		To:              to,
+		Amount:          amount.StringFixed(int32(h.currencyExponent(from))),
+		ConvertedAmount: converted,
+		Rate:            quote.Rate,
+		Rounding:        string(rounding),
+		MinorUnits:      minorUnits,
+		QuoteID:         quote.ID,
+		SnapshotID:      quote.SnapshotID,
+		QuoteUpdatedAt:  quote.UpdatedAt,
+		Freshness:       quoteResponse.Freshness,
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"from":             from,
+		"to":               to,
+		"rate":             quote.Rate,
+		"converted_amount": converted,
+	}).Info("Amount converted")
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы кодов ISO 4217
+var (
+	currencyCodePattern    = regexp.MustCompile(`^[A-Z]{3}$`)
+	currencyNumericPattern = regexp.MustCompile(`^[0-9]{3}$`)
+)
+
+// Максимальное количество минимальных единиц валюты по ISO 4217
+const maxMinorUnits = 4
+
+// Проверяем, что валюта есть в реестре и включена
+func (h *Handler) checkCurrency(code string) error {
+	if !h.currencies.IsEnabled(code) {
+		return fmt.Errorf("Currency '%s' is not supported. Supported currencies: %v", code, h.currencies.Enabled())
+	}
+	return nil
+}
+
+// Получаем количество минимальных единиц валюты из реестра, иначе по ISO 4217
+func (h *Handler) currencyExponent(code string) int {
+	if registered, exists := h.currencies.Get(code); exists {
+		return registered.MinorUnits
+	}
+	return utils.CurrencyExponent(code)
+}
+
+// @Summary Реестр валют
+// @Description Возвращает все валюты реестра с метаданными ISO 4217, включая отключённые
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.CurrenciesResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/currencies [get]
+func (h *Handler) GetCurrencyRegistry(w http.ResponseWriter, r *http.Request) {
+	currencies, err := h.db.GetCurrencies()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get currencies")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get currencies")
+		return
+	}
+
+	response := models.CurrenciesResponse{Currencies: currencies}
+	if response.Currencies == nil {
+		response.Currencies = []*models.Currency{}
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// @Summary Добавить валюту в реестр
+// @Description Добавляет валюту без перезапуска сервиса: она сразу доступна в API и запрашивается у провайдеров при следующем обновлении.
+// @Description Для кодов из встроенного справочника ISO 4217 numeric_code, minor_units и name можно не указывать.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Param request body models.CurrencyRequest true "Валюта и её метаданные"
+// @Success 201 {object} models.Currency
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse "Не передан или неверен ADMIN_TOKEN"
+// @Failure 409 {object} models.ErrorResponse "Валюта уже есть в реестре"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/currencies [post]
+func (h *Handler) CreateCurrency(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req models.CurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	newCurrency, err := resolveNewCurrency(req)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	created, err := h.db.CreateCurrency(newCurrency)
+	if err != nil {
+		if errors.Is(err, database.ErrCurrencyExists) {
+			h.writeErrorResponse(w, http.StatusConflict, "Already exists",
+				fmt.Sprintf("Currency %s is already in the registry", newCurrency.Code))
+			return
+		}
+		h.logger.WithError(err).WithField("currency", newCurrency.Code).Error("Failed to create currency")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to create currency")
+		return
+	}
+
+	h.currencies.Put(created)
+
+	h.logger.WithFields(logrus.Fields{
+		"currency": created.Code,
+		"enabled":  created.Enabled,
+	}).Info("Currency added to registry")
+
+	h.writeJSONResponse(w, http.StatusCreated, created)
+}
+
+// @Summary Изменить валюту реестра
+// @Description Включает или отключает валюту и меняет название или минимальные единицы. Коды ISO 4217 изменить нельзя, USD отключить нельзя.
+// @Description Отключённая валюта перестаёт приниматься API и запрашиваться у провайдеров.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <ADMIN_TOKEN>"
+// @Param code path string true "Код валюты ISO 4217"
+// @Param request body models.CurrencyRequest true "Новые значения"
+// @Success 200 {object} models.Currency
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse "Не передан или неверен ADMIN_TOKEN"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/currencies/{code} [put]
+func (h *Handler) UpdateCurrency(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(mux.Vars(r)["code"]))
+
+	existing, err := h.db.GetCurrency(code)
+	if err != nil {
+		h.logger.WithError(err).WithField("currency", code).Error("Failed to get currency")
+		h.writeLookupError(w, err, "Currency not found: "+code)
+		return
+	}
+
+	var req models.CurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	// Коды задают валюту, поэтому их изменение требует добавления новой валюты
+	if (req.Code != "" && !strings.EqualFold(req.Code, existing.Code)) || (req.NumericCode != "" && req.NumericCode != existing.NumericCode) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "ISO 4217 codes of a currency cannot be changed")
+		return
+	}
+
+	name := existing.Name
+	if strings.TrimSpace(req.Name) != "" {
+		name = strings.TrimSpace(req.Name)
+	}
+	minorUnits := existing.MinorUnits
+	if req.MinorUnits != nil {
+		minorUnits = *req.MinorUnits
+	}
+	if minorUnits < 0 || minorUnits > maxMinorUnits {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", fmt.Sprintf("minor_units must be between 0 and %d", maxMinorUnits))
+		return
+	}
+	enabled := existing.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	// Провайдеры возвращают курсы относительно USD, без него кросс-курсы не рассчитать
+	if code == models.USD && !enabled {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "USD is the base currency of rate providers and cannot be disabled")
+		return
+	}
+
+	updated, err := h.db.UpdateCurrency(code, name, minorUnits, enabled)
+	if err != nil {
+		h.logger.WithError(err).WithField("currency", code).Error("Failed to update currency")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to update currency")
+		return
+	}
+
+	h.currencies.Put(updated)
+
+	h.logger.WithFields(logrus.Fields{
+		"currency": updated.Code,
+		"enabled":  updated.Enabled,
+	}).Info("Currency updated in registry")
+
+	h.writeJSONResponse(w, http.StatusOK, updated)
+}
+
+// Проверяем новую валюту и дополняем метаданные из справочника ISO 4217
+func resolveNewCurrency(req models.CurrencyRequest) (*models.Currency, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !currencyCodePattern.MatchString(code) {
+		return nil, fmt.Errorf("code must be a three-letter ISO 4217 code")
+	}
+
+	resolved, known := currency.Lookup(code)
+	resolved.Code = code
+	if !known {
+		resolved.MinorUnits = 2
+	}
+
+	if req.NumericCode != "" {
+		resolved.NumericCode = req.NumericCode
+	}
+	if !currencyNumericPattern.MatchString(resolved.NumericCode) {
+		return nil, fmt.Errorf("numeric_code must be a three-digit ISO 4217 code")
+	}
+
+	if strings.TrimSpace(req.Name) != "" {
+		resolved.Name = strings.TrimSpace(req.Name)
+	}
+	if resolved.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if req.MinorUnits != nil {
+		resolved.MinorUnits = *req.MinorUnits
+	}
+	if resolved.MinorUnits < 0 || resolved.MinorUnits > maxMinorUnits {
+		return nil, fmt.Errorf("minor_units must be between 0 and %d", maxMinorUnits)
+	}
+
+	resolved.Enabled = true
+	if req.Enabled != nil {
+		resolved.Enabled = *req.Enabled
+	}
+
+	return &resolved, nil
+}
+
+// @Summary Поддерживаемые валюты
+// @Description Возвращает валюты, которые принимают эндпоинты API, с метаданными ISO 4217. Отключённые валюты не возвращаются.
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} models.CurrenciesResponse
+// @Router /currencies [get]
+func (h *Handler) GetCurrencies(w http.ResponseWriter, r *http.Request) {
+	h.writeJSONResponse(w, http.StatusOK, models.CurrenciesResponse{Currencies: h.currencies.EnabledCurrencies()})
+}
+
+// Проверяем ADMIN_TOKEN из заголовка Authorization: Bearer <token> и отвечаем 401, если он не подошёл.
+// Без настроенного токена изменяющие административные эндпоинты недоступны
+func (h *Handler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.adminToken != "" {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if found && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.adminToken)) == 1 {
+			return true
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+	h.writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized", "Valid ADMIN_TOKEN is required in Authorization header")
+	return false
+}
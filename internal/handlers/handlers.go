@@ -1,64 +1,132 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
 	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/external"
+	"go_plata_task_v2/internal/idgen"
 	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
+	"go_plata_task_v2/internal/webhook"
 
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
+// Ограничения на размер страницы истории котировок
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// Интервал опроса незавершённого запроса по умолчанию (заголовок Retry-After)
+const defaultPollInterval = 5 * time.Second
+
+// Заголовок с ID клиента, по которому выбирается секрет подписи webhook
+const clientIDHeader = "X-Client-ID"
+
+// Ограничения синхронного ожидания обновления котировки (параметр wait)
+const (
+	maxUpdateWait          = 30 * time.Second
+	updateWaitPollInterval = time.Second // Проверка статуса в БД на случай, если запрос обработала другая реплика
+)
+
+// UpdateTrigger запускает внеочередную обработку ожидающих запросов
+type UpdateTrigger interface {
+	Trigger()
+}
+
 //  Зависимости для обработчиков
 type Handler struct {
-	db                  database.DatabaseInterface
-	logger              *logrus.Logger
-	supportedCurrencies []string
+	db                    database.DatabaseInterface
+	logger                *logrus.Logger
+	currencies            *currency.Registry // Реестр валют для проверки запросов
+	providerHealth        external.HealthReporter
+	pollInterval          time.Duration     // Рекомендуемый интервал опроса незавершённых запросов
+	webhookSecrets        map[string]string // Секреты подписи webhook по ID клиента
+	allowPrivateCallbacks bool              // Разрешить адреса webhook в частных сетях
+	hub                   *pubsub.Hub       // Шина обновлений котировок от воркера
+	quotes                config.QuotesConfig
+	pricing               config.PricingConfig // Спреды и наценки для bid и ask
+	updateTrigger         UpdateTrigger        // Пробуждение воркера для синхронных запросов
+	wsAllowedOrigins      []string             // Сторонние источники, которым разрешён WebSocket API
+	adminToken            string               // Токен изменяющих административных эндпоинтов
 }
 
 // Создаём новый экземпляр Handler
-func New(db database.DatabaseInterface, logger *logrus.Logger, supportedCurrencies []string) *Handler {
+func New(db database.DatabaseInterface, logger *logrus.Logger, currencies *currency.Registry, providerHealth external.HealthReporter, pollInterval time.Duration, webhooks config.WebhookConfig, hub *pubsub.Hub, quotes config.QuotesConfig, pricing config.PricingConfig, updateTrigger UpdateTrigger, wsAllowedOrigins []string, adminToken string) *Handler {
 	return &Handler{
-		db:                  db,
-		logger:              logger,
-		supportedCurrencies: supportedCurrencies,
+		db:                    db,
+		logger:                logger,
+		currencies:            currencies,
+		providerHealth:        providerHealth,
+		pollInterval:          pollInterval,
+		webhookSecrets:        webhooks.Secrets,
+		allowPrivateCallbacks: webhooks.AllowPrivateNetworks,
+		hub:                   hub,
+		quotes:                quotes,
+		pricing:               pricing,
+		updateTrigger:         updateTrigger,
+		wsAllowedOrigins:      wsAllowedOrigins,
+		adminToken:            adminToken,
 	}
 }
 
 // @Summary Обновить котировку валютной пары
 // @Description Создает запрос на обновление котировки валютной пары (например, EUR/MXN). Обновление происходит в фоновом режиме.
+// @Description Если указан callback_url, при переходе запроса в completed, failed или dead на него отправляется подписанный webhook.
+// @Description С параметром wait запрос обрабатывается сразу, а ответ ждёт завершения обновления: 200 с котировкой, 409 для dead запроса или 202 по истечении wait.
 // @Tags quotes
 // @Accept json
 // @Produce json
+// @Param X-Client-ID header string false "ID клиента (обязателен вместе с callback_url)"
+// @Param wait query string false "Время ожидания обновления, например 5s (максимум 30s)"
+// @Param tier query string false "Уровень клиента для наценки в bid и ask (с wait)"
 // @Param request body models.UpdateQuoteRequest true "Запрос на обновление котировки"
-// @Success 200 {object} models.UpdateQuoteResponse
+// @Success 200 {object} models.UpdateQuoteResponse "Запрос принят (без wait)"
+// @Success 202 {object} models.UpdateQuoteResponse "Обновление не завершилось за время wait"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Запрос окончательно завершился ошибкой (с wait)"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /quotes/update [post]
 func (h *Handler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
-	var req models.UpdateQuoteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+	wait, err := parseUpdateWait(r.URL.Query().Get("wait"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
 
-	// Валидация
-	if strings.TrimSpace(req.From) == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "From currency is required")
+	tier, markupBps, err := h.parseTier(r.URL.Query())
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
-	if strings.TrimSpace(req.To) == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "To currency is required")
+
+	var req models.UpdateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
 		return
 	}
 
-	// Нормализуем валюты (делаем заглавными)
-	from := strings.ToUpper(strings.TrimSpace(req.From))
-	to := strings.ToUpper(strings.TrimSpace(req.To))
+	// Валидация и нормализация валют
+	from, to, err := h.normalizeCurrencyPair(req.From, req.To)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
 
 	// Проверяем, что валюты разные
 	if from == to {
@@ -66,16 +134,13 @@ func (h *Handler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверка поддерживаемых валют
-	if !models.IsSupportedCurrencyFromList(from, h.supportedCurrencies) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error",
-			fmt.Sprintf("Currency '%s' is not supported. Supported currencies: %v", from, h.supportedCurrencies))
-		return
-	}
-	if !models.IsSupportedCurrencyFromList(to, h.supportedCurrencies) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error",
-			fmt.Sprintf("Currency '%s' is not supported. Supported currencies: %v", to, h.supportedCurrencies))
-		return
+	// Проверяем параметры webhook
+	clientID := strings.TrimSpace(r.Header.Get(clientIDHeader))
+	if req.CallbackURL != "" {
+		if err := h.validateCallback(clientID, req.CallbackURL); err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+			return
+		}
 	}
 
 	// Создаем или получаем существующий pending запрос (идемпотентность)
@@ -89,11 +154,23 @@ func (h *Handler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := models.UpdateQuoteResponse{
-		ID:     quoteRequest.ID,
-		From:   quoteRequest.From,
-		To:     quoteRequest.To,
-		Status: quoteRequest.Status,
+	// Подписываем клиента на изменения статуса запроса
+	if req.CallbackURL != "" {
+		if _, err := h.db.AddWebhookSubscription(quoteRequest.ID, clientID, req.CallbackURL); err != nil {
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"request_id": quoteRequest.ID,
+				"client_id":  clientID,
+			}).Error("Failed to add webhook subscription")
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to register callback")
+			return
+		}
+
+		// Воркер мог сменить статус запроса до появления подписки и разослать событие без неё
+		if err := h.enqueueMissedWebhook(quoteRequest.ID); err != nil {
+			h.logger.WithError(err).WithField("request_id", quoteRequest.ID).Error("Failed to enqueue missed webhook")
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to register callback")
+			return
+		}
 	}
 
 	h.logger.WithFields(logrus.Fields{
@@ -102,26 +179,120 @@ func (h *Handler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
 		"to":         to,
 	}).Info("Quote update request created or retrieved")
 
-	h.writeJSONResponse(w, http.StatusOK, response)
+	if wait > 0 {
+		h.waitForQuoteRequest(w, r, quoteRequest, wait, tier, markupBps)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, quoteRequest.UpdateResponse())
+}
+
+// Разбираем параметр wait запроса на обновление котировки
+func parseUpdateWait(value string) (time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid wait '%s', expected duration like 5s", value)
+	}
+	if wait <= 0 || wait > maxUpdateWait {
+		return 0, fmt.Errorf("wait must be positive and not greater than %s", maxUpdateWait)
+	}
+
+	return wait, nil
+}
+
+// Ждём завершения запроса на обновление котировки не дольше wait. Смена статуса
+// приходит от воркера через шину, а периодическая проверка в БД покрывает запросы,
+// обработанные другой репликой сервиса
+func (h *Handler) waitForQuoteRequest(w http.ResponseWriter, r *http.Request, quoteRequest *models.QuoteRequest, wait time.Duration, tier string, markupBps decimal.Decimal) {
+	// Ожидание может быть дольше WriteTimeout сервера
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Now().Add(wait + 5*time.Second)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.WithError(err).Warn("Failed to extend write deadline for quote update")
+	}
+
+	// Подписываемся до проверки статуса, чтобы не пропустить завершение запроса
+	var events <-chan pubsub.Message
+	if h.hub != nil {
+		sub := h.hub.Subscribe(streamBufferSize, pubsub.RequestTopic(quoteRequest.ID))
+		defer sub.Close()
+		events = sub.C()
+	}
+
+	if h.updateTrigger != nil {
+		h.updateTrigger.Trigger()
+	}
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	poll := time.NewTicker(updateWaitPollInterval)
+	defer poll.Stop()
+
+	for {
+		current, err := h.db.GetQuoteRequest(quoteRequest.ID)
+		if err != nil {
+			h.logger.WithError(err).WithField("request_id", quoteRequest.ID).Error("Failed to get quote request")
+		} else {
+			quoteRequest = current
+		}
+
+		switch quoteRequest.Status {
+		case "completed":
+			response, err := h.quoteForRequest(quoteRequest, tier, markupBps)
+			if err != nil {
+				h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get quote")
+				return
+			}
+			h.writeJSONResponse(w, http.StatusOK, response)
+			return
+		case "dead":
+			h.writeErrorResponse(w, http.StatusConflict, "Request failed",
+				fmt.Sprintf("Quote request failed after %d attempts: %s", quoteRequest.Attempts, quoteRequest.FailureReason))
+			return
+		}
+
+		select {
+		case <-events:
+		case <-poll.C:
+		case <-timeout.C:
+			h.setRetryAfter(w, quoteRequest)
+			h.writeJSONResponse(w, http.StatusAccepted, quoteRequest.UpdateResponse())
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
 }
 
 // @Summary Получить котировку по ID запроса
-// @Description Возвращает котировку валютной пары по ID запроса на обновление
+// @Description Возвращает курс, которым выполнен запрос на обновление, даже если пара с тех пор обновилась. Последнюю котировку пары возвращает /quotes/latest.
 // @Tags quotes
 // @Accept json
 // @Produce json
 // @Param id path string true "ID запроса на обновление котировки"
+// @Param tier query string false "Уровень клиента для наценки в bid и ask"
 // @Success 200 {object} models.QuoteResponse
+// @Success 202 {object} models.QuoteRequestStatusResponse "Запрос ещё обрабатывается, повторить через Retry-After"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Запрос окончательно завершился ошибкой"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /quotes/{id} [get]
 func (h *Handler) GetQuoteByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	requestID := vars["id"]
 
-	if strings.TrimSpace(requestID) == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Request ID is required")
+	if err := validateRequestID(requestID); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	tier, markupBps, err := h.parseTier(r.URL.Query())
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
 
@@ -129,47 +300,313 @@ func (h *Handler) GetQuoteByID(w http.ResponseWriter, r *http.Request) {
 	quoteRequest, err := h.db.GetQuoteRequest(requestID)
 	if err != nil {
 		h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to get quote request")
-		h.writeErrorResponse(w, http.StatusNotFound, "Not found", "Quote request not found")
+		h.writeLookupError(w, err, "Quote request not found")
 		return
 	}
 
-	// Проверяем статус запроса
-	if quoteRequest.Status != "completed" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Request not completed",
-			"Quote request is not completed yet. Status: "+quoteRequest.Status)
+	// Проверяем статус запроса: незавершённый запрос ещё можно дождаться,
+	// а dead запрос котировку уже не получит
+	switch quoteRequest.Status {
+	case "completed":
+	case "dead":
+		h.writeErrorResponse(w, http.StatusConflict, "Request failed",
+			fmt.Sprintf("Quote request failed after %d attempts: %s", quoteRequest.Attempts, quoteRequest.FailureReason))
+		return
+	default:
+		h.setRetryAfter(w, quoteRequest)
+		h.writeJSONResponse(w, http.StatusAccepted, quoteRequest.StatusResponse())
 		return
 	}
 
 	// Получаем котировку
-	quote, err := h.db.GetQuote(quoteRequest.From, quoteRequest.To)
+	response, err := h.quoteForRequest(quoteRequest, tier, markupBps)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get quote")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"from":       response.From,
+		"to":         response.To,
+		"rate":       response.Rate,
+	}).Info("Quote retrieved by ID")
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// Проверяем формат ID запроса на обновление, чтобы не искать в базе заведомо несуществующий
+func validateRequestID(requestID string) error {
+	if strings.TrimSpace(requestID) == "" {
+		return fmt.Errorf("Request ID is required")
+	}
+	if !idgen.Valid(requestID) {
+		return fmt.Errorf("Invalid request ID format: %s", requestID)
+	}
+	return nil
+}
+
+// Получаем котировку, которой выполнен запрос, с ценами для уровня клиента
+func (h *Handler) quoteForRequest(quoteRequest *models.QuoteRequest, tier string, markupBps decimal.Decimal) (*models.QuoteResponse, error) {
+	response, err := h.requestQuote(quoteRequest)
 	if err != nil {
 		h.logger.WithError(err).WithFields(logrus.Fields{
-			"request_id": requestID,
+			"request_id": quoteRequest.ID,
 			"from":       quoteRequest.From,
 			"to":         quoteRequest.To,
 		}).Error("Failed to get quote")
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get quote")
+		return nil, err
+	}
+
+	if err := h.applyPricing(&response, tier, markupBps); err != nil {
+		h.logger.WithError(err).WithField("request_id", quoteRequest.ID).Error("Failed to price quote")
+		return nil, err
+	}
+	return &response, nil
+}
+
+// @Summary Получить состояние запроса на обновление котировки
+// @Description Возвращает жизненный цикл запроса: статус, количество попыток, причину последней неудачи, временные метки и курс, которым запрос выполнен.
+// @Description Пока запрос обрабатывается (pending, processing или failed с запланированным повтором), возвращается 202 с заголовком Retry-After.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID запроса на обновление котировки"
+// @Success 200 {object} models.QuoteRequestStatusResponse "Запрос завершён (completed или dead)"
+// @Success 202 {object} models.QuoteRequestStatusResponse "Запрос ещё обрабатывается"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /quotes/requests/{id} [get]
+func (h *Handler) GetQuoteRequestStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requestID := vars["id"]
+
+	if err := validateRequestID(requestID); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
 
-	response := models.QuoteResponse{
-		ID:        quote.ID,
-		From:      quote.From,
-		To:        quote.To,
-		Rate:      quote.Rate,
-		UpdatedAt: quote.UpdatedAt,
+	// Получаем запрос на обновление котировки
+	quoteRequest, err := h.db.GetQuoteRequest(requestID)
+	if err != nil {
+		h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to get quote request")
+		h.writeLookupError(w, err, "Quote request not found")
+		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"from":       quote.From,
-		"to":         quote.To,
-		"rate":       quote.Rate,
-	}).Info("Quote retrieved by ID")
+	response := quoteRequest.StatusResponse()
+
+	switch quoteRequest.Status {
+	case "completed":
+		// Добавляем курс, которым выполнен запрос. Ошибка не мешает вернуть статус
+		response.Quote = h.linkedQuote(quoteRequest)
+		h.writeJSONResponse(w, http.StatusOK, response)
+	case "dead":
+		h.writeJSONResponse(w, http.StatusOK, response)
+	default:
+		h.setRetryAfter(w, quoteRequest)
+		h.writeJSONResponse(w, http.StatusAccepted, response)
+	}
+}
+
+// Устанавливаем заголовок Retry-After для незавершённого запроса: до запланированной
+// повторной попытки для failed запроса или интервал опроса для остальных
+func (h *Handler) setRetryAfter(w http.ResponseWriter, quoteRequest *models.QuoteRequest) {
+	delay := h.pollInterval
+	if delay <= 0 {
+		delay = defaultPollInterval
+	}
+	if quoteRequest.Status == "failed" && quoteRequest.NextAttemptAt != nil {
+		if untilRetry := time.Until(*quoteRequest.NextAttemptAt); untilRetry > 0 {
+			delay = untilRetry
+		}
+	}
+
+	seconds := int64((delay + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// @Summary Получить журнал доставок webhook по запросу
+// @Description Возвращает попытки доставки webhook клиента по запросу на обновление котировки, от новых к старым.
+// @Description Клиент подтверждает доступ своим секретом подписи webhook в заголовке Authorization: Bearer <secret>.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "ID запроса на обновление котировки"
+// @Param X-Client-ID header string true "ID клиента"
+// @Param Authorization header string true "Bearer <секрет подписи webhook клиента>"
+// @Success 200 {object} models.WebhookDeliveriesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /quotes/requests/{id}/webhooks [get]
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requestID := vars["id"]
+
+	if err := validateRequestID(requestID); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	clientID := strings.TrimSpace(r.Header.Get(clientIDHeader))
+	if clientID == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", clientIDHeader+" header is required")
+		return
+	}
+
+	// Журнал содержит payload и адреса клиента, поэтому одного ID клиента недостаточно
+	if !h.authenticateWebhookClient(clientID, r.Header.Get("Authorization")) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="webhooks"`)
+		h.writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized", "Valid webhook secret is required in Authorization header")
+		return
+	}
+
+	// Проверяем, что запрос существует
+	if _, err := h.db.GetQuoteRequest(requestID); err != nil {
+		h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to get quote request")
+		h.writeLookupError(w, err, "Quote request not found")
+		return
+	}
+
+	deliveries, err := h.db.GetWebhookDeliveries(requestID, clientID)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"request_id": requestID,
+			"client_id":  clientID,
+		}).Error("Failed to get webhook deliveries")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get webhook deliveries")
+		return
+	}
+
+	response := models.WebhookDeliveriesResponse{
+		RequestID:  requestID,
+		Deliveries: deliveries,
+	}
+	if response.Deliveries == nil {
+		response.Deliveries = []*models.WebhookDelivery{}
+	}
 
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// Проверяем секрет подписи webhook клиента из заголовка Authorization: Bearer <secret>
+func (h *Handler) authenticateWebhookClient(clientID, authorization string) bool {
+	secret, exists := h.webhookSecrets[clientID]
+	if !exists || secret == "" {
+		return false
+	}
+
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(secret)) == 1
+}
+
+// Получаем котировку, которой выполнен запрос, а не последнюю котировку пары.
+// У запросов, завершённых до появления quote_history_id, ссылки нет, для них возвращаем текущую котировку.
+// В обоих случаях id ответа совпадает с ID запроса
+func (h *Handler) requestQuote(quoteRequest *models.QuoteRequest) (models.QuoteResponse, error) {
+	if quoteRequest.QuoteHistoryID == nil {
+		quote, err := h.db.GetQuote(quoteRequest.From, quoteRequest.To)
+		if err != nil {
+			return models.QuoteResponse{}, err
+		}
+		response := quote.Response()
+		response.ID = quoteRequest.ID
+		return response, nil
+	}
+
+	entry, err := h.db.GetQuoteHistoryEntry(*quoteRequest.QuoteHistoryID)
+	if err != nil {
+		return models.QuoteResponse{}, err
+	}
+	return entry.Response(quoteRequest.ID), nil
+}
+
+// Получаем запись истории, которой выполнен запрос, или nil, если её нет
+func (h *Handler) linkedQuote(quoteRequest *models.QuoteRequest) *models.QuoteHistoryItem {
+	if quoteRequest.QuoteHistoryID == nil {
+		return nil
+	}
+
+	entry, err := h.db.GetQuoteHistoryEntry(*quoteRequest.QuoteHistoryID)
+	if err != nil {
+		h.logger.WithError(err).WithField("request_id", quoteRequest.ID).Error("Failed to get linked quote")
+		return nil
+	}
+
+	item := entry.Item()
+	return &item
+}
+
+// Перечитываем запрос после подписки на webhook и, если он уже в completed, failed или dead,
+// ставим событие в outbox сами. Если воркер добавил то же событие, outbox оставит одну доставку
+func (h *Handler) enqueueMissedWebhook(requestID string) error {
+	quoteRequest, err := h.db.GetQuoteRequest(requestID)
+	if err != nil {
+		return err
+	}
+
+	switch quoteRequest.Status {
+	case "completed", "failed", "dead":
+	default:
+		// Событие разошлёт воркер при смене статуса, подписка уже учтена
+		return nil
+	}
+
+	data := quoteRequest.StatusResponse()
+	if quoteRequest.Status == "completed" {
+		data.Quote = h.linkedQuote(quoteRequest)
+	}
+
+	payload := models.WebhookPayload{
+		Event:      "quote_request." + quoteRequest.Status,
+		OccurredAt: quoteRequest.UpdatedAt,
+		Data:       data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	if _, err := h.db.EnqueueWebhookDeliveries(requestID, payload.Event, body); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Проверяем клиента и адрес webhook
+func (h *Handler) validateCallback(clientID, callbackURL string) error {
+	if clientID == "" {
+		return fmt.Errorf("%s header is required when callback_url is set", clientIDHeader)
+	}
+	if _, exists := h.webhookSecrets[clientID]; !exists {
+		return fmt.Errorf("Client '%s' is not registered for webhooks", clientID)
+	}
+
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+
+	// Адреса во внутренней сети запрещены. Адрес, в который разрешается имя хоста,
+	// проверяет диспетчер при каждой отправке
+	if !h.allowPrivateCallbacks {
+		if err := webhook.CheckCallbackHost(parsed.Hostname()); err != nil {
+			return fmt.Errorf("callback_url must point to a public address: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // @Summary Получить последнюю котировку валютной пары
 // @Description Возвращает последнее значение котировки для указанной валютной пары
 // @Tags quotes
@@ -177,38 +614,33 @@ func (h *Handler) GetQuoteByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param from query string true "Базовая валюта (например, EUR)"
 // @Param to query string true "Котируемая валюта (например, MXN)"
+// @Param max_age query string false "Максимальный возраст котировки: длительность (10m) или секунды (600). По умолчанию из конфигурации"
+// @Param on_stale query string false "Поведение для устаревшей котировки: refresh (по умолчанию) или reject"
+// @Param tier query string false "Уровень клиента для наценки в bid и ask"
 // @Success 200 {object} models.QuoteResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.StaleQuoteErrorResponse "Котировка устарела, обновление поставлено в очередь"
 // @Router /quotes/latest [get]
 func (h *Handler) GetLatestQuote(w http.ResponseWriter, r *http.Request) {
-	// Получаем параметры из query string
-	from := r.URL.Query().Get("from")
-	to := r.URL.Query().Get("to")
-
-	if strings.TrimSpace(from) == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "From currency is required")
-		return
-	}
-	if strings.TrimSpace(to) == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "To currency is required")
+	// Получаем параметры из query string и проверяем валюты
+	from, to, err := h.normalizeCurrencyPair(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
 
-	// Нормализуем валюты
-	from = strings.ToUpper(strings.TrimSpace(from))
-	to = strings.ToUpper(strings.TrimSpace(to))
-
-	// Проверка поддерживаемых валют
-	if !models.IsSupportedCurrencyFromList(from, h.supportedCurrencies) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error",
-			fmt.Sprintf("Currency '%s' is not supported. Supported currencies: %v", from, h.supportedCurrencies))
+	// Определяем допустимый возраст котировки и поведение для устаревшей
+	maxAge, stalePolicy, err := h.parseStaleness(r.URL.Query(), from, to)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
-	if !models.IsSupportedCurrencyFromList(to, h.supportedCurrencies) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error",
-			fmt.Sprintf("Currency '%s' is not supported. Supported currencies: %v", to, h.supportedCurrencies))
+
+	tier, markupBps, err := h.parseTier(r.URL.Query())
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
 		return
 	}
 
@@ -219,16 +651,30 @@ func (h *Handler) GetLatestQuote(w http.ResponseWriter, r *http.Request) {
 			"from": from,
 			"to":   to,
 		}).Error("Failed to get latest quote")
-		h.writeErrorResponse(w, http.StatusNotFound, "Not found", "Quote not found for currency pair: "+from+"/"+to)
+		h.writeLookupError(w, err, "Quote not found for currency pair: "+from+"/"+to)
 		return
 	}
 
-	response := models.QuoteResponse{
-		ID:        quote.ID,
-		From:      quote.From,
-		To:        quote.To,
-		Rate:      quote.Rate,
-		UpdatedAt: quote.UpdatedAt,
+	response := quote.Response()
+	if err := h.applyPricing(&response, tier, markupBps); err != nil {
+		h.logger.WithError(err).Error("Failed to price latest quote")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to price quote")
+		return
+	}
+
+	// Проверяем возраст котировки. Устаревшая котировка обновляется в фоне,
+	// а клиент получает её с признаком stale или 503 в зависимости от политики
+	refresh := h.checkFreshness(&response, maxAge, stalePolicy)
+	if response.Freshness != nil && response.Freshness.Stale && stalePolicy == config.StalePolicyReject {
+		if refresh != nil {
+			h.setRetryAfter(w, refresh)
+		}
+		h.writeJSONResponse(w, http.StatusServiceUnavailable, models.StaleQuoteErrorResponse{
+			Error:   "Stale quote",
+			Message: fmt.Sprintf("Quote for %s/%s is older than %s, refresh has been requested", from, to, maxAge),
+			Quote:   response,
+		})
+		return
 	}
 
 	h.logger.WithFields(logrus.Fields{
@@ -240,6 +686,203 @@ func (h *Handler) GetLatestQuote(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// @Summary Получить историю котировок валютной пары
+// @Description Возвращает сохранённые значения курса валютной пары от новых к старым с курсорной пагинацией
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param from query string true "Базовая валюта (например, EUR)"
+// @Param to query string true "Котируемая валюта (например, MXN)"
+// @Param since query string false "Начало периода в формате RFC3339 (включительно)"
+// @Param until query string false "Конец периода в формате RFC3339 (включительно)"
+// @Param limit query int false "Количество записей на странице (по умолчанию 100, максимум 1000)"
+// @Param cursor query string false "Курсор следующей страницы из поля next_cursor"
+// @Success 200 {object} models.QuoteHistoryResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /quotes/history [get]
+func (h *Handler) GetQuoteHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := h.normalizeCurrencyPair(query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	filter := models.QuoteHistoryFilter{
+		From:  from,
+		To:    to,
+		Limit: defaultHistoryLimit,
+	}
+
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'since' must be in RFC3339 format")
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'until' must be in RFC3339 format")
+			return
+		}
+		filter.Until = &until
+	}
+	if filter.Since != nil && filter.Until != nil && filter.Since.After(*filter.Until) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'since' must not be after 'until'")
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error",
+				fmt.Sprintf("Parameter 'limit' must be between 1 and %d", maxHistoryLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodeHistoryCursor(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Invalid cursor")
+			return
+		}
+		filter.After = cursor
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	entries, err := h.db.GetQuoteHistory(filter)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+		}).Error("Failed to get quote history")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get quote history")
+		return
+	}
+
+	response := models.QuoteHistoryResponse{
+		From:  from,
+		To:    to,
+		Items: make([]models.QuoteHistoryItem, 0, len(entries)),
+	}
+
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		last := entries[len(entries)-1]
+		response.NextCursor = encodeHistoryCursor(models.QuoteHistoryCursor{RecordedAt: last.RecordedAt, ID: last.ID})
+	}
+
+	for _, entry := range entries {
+		response.Items = append(response.Items, entry.Item())
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"from":  from,
+		"to":    to,
+		"count": len(response.Items),
+	}).Info("Quote history retrieved")
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// @Summary Получить курс валютной пары на момент времени
+// @Description Возвращает курс, который был известен сервису на указанный момент времени, и его возраст на этот момент
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param from query string true "Базовая валюта (например, EUR)"
+// @Param to query string true "Котируемая валюта (например, MXN)"
+// @Param ts query string true "Момент времени в формате RFC3339"
+// @Success 200 {object} models.QuoteAtResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /quotes/at [get]
+func (h *Handler) GetQuoteAt(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, to, err := h.normalizeCurrencyPair(query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	value := query.Get("ts")
+	if strings.TrimSpace(value) == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'ts' is required")
+		return
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'ts' must be in RFC3339 format")
+		return
+	}
+	if at.After(time.Now()) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Parameter 'ts' must not be in the future")
+		return
+	}
+
+	// Ищем последнюю запись истории не позже запрошенного момента
+	entry, err := h.db.GetQuoteAt(from, to, at)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+			"ts":   value,
+		}).Error("Failed to get quote at point in time")
+		h.writeLookupError(w, err, "No quote known at "+value+" for currency pair: "+from+"/"+to)
+		return
+	}
+
+	response := models.QuoteAtResponse{
+		From:        entry.From,
+		To:          entry.To,
+		Rate:        entry.Rate,
+		Sources:     entry.Sources,
+		SnapshotID:  entry.SnapshotID,
+		EffectiveAt: entry.RecordedAt,
+		RequestedAt: at,
+		AgeSeconds:  int64(at.Sub(entry.RecordedAt).Seconds()),
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+		"ts":   value,
+		"rate": entry.Rate,
+	}).Info("Point-in-time quote retrieved")
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// @Summary Состояние провайдеров курсов
+// @Description Возвращает цепочку провайдеров курсов с состоянием circuit breaker и отметкой активного провайдера
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.ProvidersResponse
+// @Router /admin/providers [get]
+func (h *Handler) GetProviders(w http.ResponseWriter, r *http.Request) {
+	response := models.ProvidersResponse{
+		Providers: []models.ProviderStatus{},
+	}
+
+	if h.providerHealth != nil {
+		response.Providers = h.providerHealth.ProviderStatuses()
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
 // @Summary Health check
 // @Description Проверка состояния сервиса
 // @Tags system
@@ -256,6 +899,180 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// Нормализуем валютную пару и проверяем, что обе валюты поддерживаются
+func (h *Handler) normalizeCurrencyPair(from, to string) (string, string, error) {
+	if strings.TrimSpace(from) == "" {
+		return "", "", fmt.Errorf("From currency is required")
+	}
+	if strings.TrimSpace(to) == "" {
+		return "", "", fmt.Errorf("To currency is required")
+	}
+
+	// Нормализуем валюты (делаем заглавными)
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+
+	// Проверка валют по реестру
+	if err := h.checkCurrency(from); err != nil {
+		return "", "", err
+	}
+	if err := h.checkCurrency(to); err != nil {
+		return "", "", err
+	}
+
+	return from, to, nil
+}
+
+// Заполняем актуальность котировки. Для устаревшей котировки ставим обновление пары
+// в очередь и возвращаем запрос на обновление
+func (h *Handler) checkFreshness(response *models.QuoteResponse, maxAge time.Duration, stalePolicy string) *models.QuoteRequest {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	age := time.Since(response.UpdatedAt)
+	response.Freshness = quoteFreshness(age, maxAge)
+	if !response.Freshness.Stale {
+		return nil
+	}
+
+	refresh, err := h.db.CreateOrGetPendingQuoteRequest(response.From, response.To)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": response.From,
+			"to":   response.To,
+		}).Error("Failed to enqueue stale quote refresh")
+	} else {
+		response.Freshness.RefreshRequestID = refresh.ID
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"from":       response.From,
+		"to":         response.To,
+		"age":        age,
+		"max_age":    maxAge,
+		"policy":     stalePolicy,
+		"request_id": response.Freshness.RefreshRequestID,
+	}).Warn("Stale quote requested")
+
+	return refresh
+}
+
+// Сравниваем возраст котировки с максимально допустимым (0 — без ограничения)
+func quoteFreshness(age, maxAge time.Duration) *models.QuoteFreshness {
+	return &models.QuoteFreshness{
+		AgeSeconds:    int64(age / time.Second),
+		MaxAgeSeconds: int64(maxAge / time.Second),
+		Stale:         maxAge > 0 && age > maxAge,
+	}
+}
+
+// Разбираем параметры max_age и on_stale. Без параметров используются максимальный
+// возраст пары и политика из конфигурации
+func (h *Handler) parseStaleness(query url.Values, from, to string) (time.Duration, string, error) {
+	maxAge := h.quotes.MaxAgeFor(from, to)
+	if value := strings.TrimSpace(query.Get("max_age")); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			seconds, convErr := strconv.ParseInt(value, 10, 64)
+			if convErr != nil {
+				return 0, "", fmt.Errorf("Invalid max_age '%s', expected duration like 10m or number of seconds", value)
+			}
+			parsed = time.Duration(seconds) * time.Second
+		}
+		if parsed <= 0 {
+			return 0, "", fmt.Errorf("max_age must be positive")
+		}
+		maxAge = parsed
+	}
+
+	policy := h.quotes.StalePolicy
+	if value := strings.TrimSpace(query.Get("on_stale")); value != "" {
+		policy = strings.ToLower(value)
+	}
+	switch policy {
+	case "":
+		policy = config.StalePolicyRefresh
+	case config.StalePolicyRefresh, config.StalePolicyReject:
+	default:
+		return 0, "", fmt.Errorf("Invalid on_stale '%s', expected refresh or reject", policy)
+	}
+
+	return maxAge, policy, nil
+}
+
+// Разбираем список пар вида EUR/MXN,USD/EUR. Дубликаты пропускаются
+func (h *Handler) parseCurrencyPairs(value string) ([]models.CurrencyPair, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("pairs parameter is required")
+	}
+
+	seen := make(map[string]bool)
+	var pairs []models.CurrencyPair
+	for _, raw := range strings.Split(value, ",") {
+		pair, err := h.parseCurrencyPair(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[pair.String()] {
+			continue
+		}
+		seen[pair.String()] = true
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, nil
+}
+
+// Разбираем и проверяем пару вида EUR/MXN
+func (h *Handler) parseCurrencyPair(raw string) (models.CurrencyPair, error) {
+	parts := strings.Split(strings.TrimSpace(raw), "/")
+	if len(parts) != 2 {
+		return models.CurrencyPair{}, fmt.Errorf("Invalid pair '%s', expected FROM/TO", strings.TrimSpace(raw))
+	}
+
+	from, to, err := h.normalizeCurrencyPair(parts[0], parts[1])
+	if err != nil {
+		return models.CurrencyPair{}, err
+	}
+	if from == to {
+		return models.CurrencyPair{}, fmt.Errorf("Invalid pair '%s': currencies must be different", strings.TrimSpace(raw))
+	}
+
+	return models.CurrencyPair{From: from, To: to}, nil
+}
+
+// Кодируем позицию в истории котировок в непрозрачный курсор
+func encodeHistoryCursor(cursor models.QuoteHistoryCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.RecordedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Декодируем курсор истории котировок
+func decodeHistoryCursor(value string) (*models.QuoteHistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor timestamp: %w", err)
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor id: %w", err)
+	}
+
+	return &models.QuoteHistoryCursor{RecordedAt: time.Unix(0, nanos), ID: id}, nil
+}
+
 // Записываем JSON ответ
 func (h *Handler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -267,6 +1084,16 @@ func (h *Handler) writeJSONResponse(w http.ResponseWriter, statusCode int, data
 	}
 }
 
+// Отвечаем 404, если запись не найдена, и 500 при остальных ошибках базы данных,
+// чтобы клиенты не считали сбой базы отсутствием записи
+func (h *Handler) writeLookupError(w http.ResponseWriter, err error, notFoundMessage string) {
+	if errors.Is(err, database.ErrNotFound) {
+		h.writeErrorResponse(w, http.StatusNotFound, "Not found", notFoundMessage)
+		return
+	}
+	h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to read from database")
+}
+
 // Записываем JSON ответ с ошибкой
 func (h *Handler) writeErrorResponse(w http.ResponseWriter, statusCode int, error, message string) {
 	response := models.ErrorResponse{
@@ -279,7 +1106,30 @@ func (h *Handler) writeErrorResponse(w http.ResponseWriter, statusCode int, erro
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/quotes/update", h.UpdateQuote).Methods("POST")
+	router.HandleFunc("/quotes/update/batch", h.BatchUpdateQuotes).Methods("POST")
 	router.HandleFunc("/quotes/latest", h.GetLatestQuote).Methods("GET")
+	router.HandleFunc("/quotes/latest/batch", h.GetLatestQuotesBatch).Methods("GET")
+	router.HandleFunc("/quotes/history", h.GetQuoteHistory).Methods("GET")
+	router.HandleFunc("/quotes/at", h.GetQuoteAt).Methods("GET")
+	router.HandleFunc("/quotes/matrix", h.GetQuoteMatrix).Methods("GET")
+	router.HandleFunc("/quotes/stream", h.StreamQuotes).Methods("GET")
+	router.HandleFunc("/quotes/requests/{id}", h.GetQuoteRequestStatus).Methods("GET")
+	router.HandleFunc("/quotes/requests/{id}/webhooks", h.GetWebhookDeliveries).Methods("GET")
 	router.HandleFunc("/quotes/{id}", h.GetQuoteByID).Methods("GET")
+	router.HandleFunc("/convert", h.Convert).Methods("GET")
+	router.HandleFunc("/currencies", h.GetCurrencies).Methods("GET")
+	router.HandleFunc("/pairs", h.GetPairs).Methods("GET")
+	router.HandleFunc("/watchlist", h.GetWatchlist).Methods("GET")
+	router.HandleFunc("/watchlist", h.CreateWatchlistEntry).Methods("POST")
+	router.HandleFunc("/watchlist/{id}", h.GetWatchlistEntry).Methods("GET")
+	router.HandleFunc("/watchlist/{id}", h.UpdateWatchlistEntry).Methods("PUT")
+	router.HandleFunc("/watchlist/{id}", h.DeleteWatchlistEntry).Methods("DELETE")
+	router.HandleFunc("/rates/snapshots/latest", h.GetLatestRateSnapshot).Methods("GET")
+	router.HandleFunc("/rates/snapshots/{id}", h.GetRateSnapshot).Methods("GET")
+	router.HandleFunc("/admin/providers", h.GetProviders).Methods("GET")
+	router.HandleFunc("/admin/currencies", h.GetCurrencyRegistry).Methods("GET")
+	router.HandleFunc("/admin/currencies", h.CreateCurrency).Methods("POST")
+	router.HandleFunc("/admin/currencies/{code}", h.UpdateCurrency).Methods("PUT")
+	router.HandleFunc("/ws", h.ServeWebSocket).Methods("GET")
 	router.HandleFunc("/health", h.Health).Methods("GET")
 }
@@ -1,15 +1,28 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/external"
 	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -21,11 +34,16 @@ type DatabaseInterface interface {
 	GetQuoteRequest(id string) (*models.QuoteRequest, error)
 	GetQuote(from, to string) (*models.Quote, error)
 	UpdateQuoteRequestStatus(id, status string) error
-	UpsertQuote(from, to string, rate float64) error
+	UpsertQuote(from, to string, rate decimal.Decimal) error
 	GetPendingQuoteRequests() ([]*models.QuoteRequest, error)
 	Close() error
 }
 
+// Курс из строкового значения
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
 // Мок для базы данных
 type MockDB struct {
 	mock.Mock
@@ -61,16 +79,166 @@ func (m *MockDB) UpdateQuoteRequestStatus(id, status string) error {
 	return args.Error(0)
 }
 
-func (m *MockDB) UpsertQuote(from, to string, rate float64) error {
-	args := m.Called(from, to, rate)
+func (m *MockDB) UpsertQuote(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) error {
+	args := m.Called(from, to, rate, sources, snapshotID)
+	return args.Error(0)
+}
+
+func (m *MockDB) RecordQuoteRequestFailure(id, status, reason, lastError string, nextAttemptAt *time.Time) error {
+	args := m.Called(id, status, reason, lastError, nextAttemptAt)
+	return args.Error(0)
+}
+
+func (m *MockDB) RequeueFailedQuoteRequests(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) AddWebhookSubscription(requestID, clientID, callbackURL string) (*models.WebhookSubscription, error) {
+	args := m.Called(requestID, clientID, callbackURL)
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockDB) EnqueueWebhookDeliveries(requestID, event string, payload []byte) (int64, error) {
+	args := m.Called(requestID, event, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockDB) MarkWebhookDelivered(id int64, statusCode int) error {
+	args := m.Called(id, statusCode)
+	return args.Error(0)
+}
+
+func (m *MockDB) RecordWebhookDeliveryFailure(id int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error {
+	args := m.Called(id, status, statusCode, lastError, nextAttemptAt)
 	return args.Error(0)
 }
 
+func (m *MockDB) GetWebhookDeliveries(requestID, clientID string) ([]*models.WebhookDelivery, error) {
+	args := m.Called(requestID, clientID)
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
 func (m *MockDB) GetPendingQuoteRequests() ([]*models.QuoteRequest, error) {
 	args := m.Called()
 	return args.Get(0).([]*models.QuoteRequest), args.Error(1)
 }
 
+func (m *MockDB) AppendQuoteHistory(from, to string, rate decimal.Decimal, sources []string, snapshotID *int64) (int64, error) {
+	args := m.Called(from, to, rate, sources, snapshotID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetQuoteHistoryEntry(id int64) (*models.QuoteHistoryEntry, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.QuoteHistoryEntry), args.Error(1)
+}
+
+func (m *MockDB) CompleteQuoteRequest(id string, quoteHistoryID *int64) error {
+	args := m.Called(id, quoteHistoryID)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetQuoteHistory(filter models.QuoteHistoryFilter) ([]*models.QuoteHistoryEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]*models.QuoteHistoryEntry), args.Error(1)
+}
+
+func (m *MockDB) GetQuoteAt(from, to string, at time.Time) (*models.QuoteHistoryEntry, error) {
+	args := m.Called(from, to, at)
+	return args.Get(0).(*models.QuoteHistoryEntry), args.Error(1)
+}
+
+func (m *MockDB) CreateWatchlistEntry(from, to, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error) {
+	args := m.Called(from, to, schedule, enabled, nextRunAt)
+	return args.Get(0).(*models.WatchlistEntry), args.Error(1)
+}
+
+func (m *MockDB) GetWatchlistEntry(id int64) (*models.WatchlistEntry, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.WatchlistEntry), args.Error(1)
+}
+
+func (m *MockDB) GetWatchlist() ([]*models.WatchlistEntry, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.WatchlistEntry), args.Error(1)
+}
+
+func (m *MockDB) UpdateWatchlistEntry(id int64, schedule string, enabled bool, nextRunAt time.Time) (*models.WatchlistEntry, error) {
+	args := m.Called(id, schedule, enabled, nextRunAt)
+	return args.Get(0).(*models.WatchlistEntry), args.Error(1)
+}
+
+func (m *MockDB) DeleteWatchlistEntry(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetDueWatchlistEntries(now time.Time) ([]*models.WatchlistEntry, error) {
+	args := m.Called(now)
+	return args.Get(0).([]*models.WatchlistEntry), args.Error(1)
+}
+
+func (m *MockDB) MarkWatchlistEntryEnqueued(id int64, runAt time.Time, requestID string, nextRunAt time.Time) error {
+	args := m.Called(id, runAt, requestID, nextRunAt)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetQuotes(pairs []models.CurrencyPair) ([]*models.Quote, error) {
+	args := m.Called(pairs)
+	return args.Get(0).([]*models.Quote), args.Error(1)
+}
+
+func (m *MockDB) ListQuotes() ([]*models.Quote, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.Quote), args.Error(1)
+}
+
+func (m *MockDB) SaveRateSnapshot(snapshot *models.RatesSnapshot) (int64, error) {
+	args := m.Called(snapshot)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetRateSnapshot(id int64) (*models.RatesSnapshot, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.RatesSnapshot), args.Error(1)
+}
+
+func (m *MockDB) GetLatestRateSnapshot() (*models.RatesSnapshot, error) {
+	args := m.Called()
+	return args.Get(0).(*models.RatesSnapshot), args.Error(1)
+}
+
+func (m *MockDB) CreateCurrency(currency *models.Currency) (*models.Currency, error) {
+	args := m.Called(currency)
+	return args.Get(0).(*models.Currency), args.Error(1)
+}
+
+func (m *MockDB) SeedCurrencies(currencies []*models.Currency) error {
+	args := m.Called(currencies)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetCurrency(code string) (*models.Currency, error) {
+	args := m.Called(code)
+	return args.Get(0).(*models.Currency), args.Error(1)
+}
+
+func (m *MockDB) GetCurrencies() ([]*models.Currency, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.Currency), args.Error(1)
+}
+
+func (m *MockDB) UpdateCurrency(code, name string, minorUnits int, enabled bool) (*models.Currency, error) {
+	args := m.Called(code, name, minorUnits, enabled)
+	return args.Get(0).(*models.Currency), args.Error(1)
+}
+
 func (m *MockDB) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -80,6 +248,7 @@ func TestUpdateQuote(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    models.UpdateQuoteRequest
+		clientID       string
 		mockSetup      func(*MockDB)
 		expectedStatus int
 		expectedError  string
@@ -161,6 +330,129 @@ func TestUpdateQuote(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "Valid request with callback",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "https://client.example.com/hooks/quotes",
+			},
+			clientID: "acme",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateOrGetPendingQuoteRequest", "EUR", "USD").Return(&models.QuoteRequest{
+					ID:     "123",
+					From:   "EUR",
+					To:     "USD",
+					Status: "pending",
+				}, nil)
+				mockDB.On("AddWebhookSubscription", "123", "acme", "https://client.example.com/hooks/quotes").
+					Return(&models.WebhookSubscription{ID: 1}, nil)
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:     "123",
+					From:   "EUR",
+					To:     "USD",
+					Status: "pending",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Request completed before callback subscription",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "https://client.example.com/hooks/quotes",
+			},
+			clientID: "acme",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateOrGetPendingQuoteRequest", "EUR", "USD").Return(&models.QuoteRequest{
+					ID:     "123",
+					From:   "EUR",
+					To:     "USD",
+					Status: "pending",
+				}, nil)
+				mockDB.On("AddWebhookSubscription", "123", "acme", "https://client.example.com/hooks/quotes").
+					Return(&models.WebhookSubscription{ID: 1}, nil)
+				historyID := int64(9)
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:             "123",
+					From:           "EUR",
+					To:             "USD",
+					Status:         "completed",
+					QuoteHistoryID: &historyID,
+				}, nil)
+				mockDB.On("GetQuoteHistoryEntry", historyID).Return(&models.QuoteHistoryEntry{
+					ID:   historyID,
+					From: "EUR",
+					To:   "USD",
+					Rate: dec("1.17"),
+				}, nil)
+				// Событие, разосланное воркером до подписки, ставится в outbox повторно
+				mockDB.On("EnqueueWebhookDeliveries", "123", "quote_request.completed", mock.MatchedBy(func(payload []byte) bool {
+					return strings.Contains(string(payload), `"rate":"1.17"`)
+				})).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Callback without client ID",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "https://client.example.com/hooks/quotes",
+			},
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "X-Client-ID header is required",
+		},
+		{
+			name: "Callback for unknown client",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "https://client.example.com/hooks/quotes",
+			},
+			clientID:       "stranger",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Client 'stranger' is not registered for webhooks",
+		},
+		{
+			name: "Invalid callback URL",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "ftp://client.example.com/hooks",
+			},
+			clientID:       "acme",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "callback_url must be an absolute http or https URL",
+		},
+		{
+			name: "Callback to cloud metadata address",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "http://169.254.169.254/latest/meta-data/",
+			},
+			clientID:       "acme",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "callback_url must point to a public address",
+		},
+		{
+			name: "Callback to localhost",
+			requestBody: models.UpdateQuoteRequest{
+				From:        "EUR",
+				To:          "USD",
+				CallbackURL: "http://localhost:8080/internal",
+			},
+			clientID:       "acme",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "callback_url must point to a public address",
+		},
 	}
 
 	for _, tt := range tests {
@@ -170,14 +462,18 @@ func TestUpdateQuote(t *testing.T) {
 
 			logger := logrus.New()
 			handler := &Handler{
-				db:                  mockDB,
-				logger:              logger,
-				supportedCurrencies: []string{"USD", "EUR", "MXN"},
+				db:             mockDB,
+				logger:         logger,
+				currencies:     currency.NewStatic("USD", "EUR", "MXN"),
+				webhookSecrets: map[string]string{"acme": "s3cret"},
 			}
 
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest("POST", "/quotes/update", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
+			if tt.clientID != "" {
+				req.Header.Set("X-Client-ID", tt.clientID)
+			}
 
 			rr := httptest.NewRecorder()
 			handler.UpdateQuote(rr, req)
@@ -196,51 +492,136 @@ func TestUpdateQuote(t *testing.T) {
 	}
 }
 
-func TestGetQuoteByID(t *testing.T) {
+// Одновременные POST /quotes/update по одной паре на настоящей PostgreSQL получают
+// один и тот же ID запроса. Тест пропускается, если TEST_DB_NAME не задана;
+// остальные параметры подключения берутся из переменных DB_*
+func TestUpdateQuote_ConcurrentRequestsShareID(t *testing.T) {
+	dbName := os.Getenv("TEST_DB_NAME")
+	if dbName == "" {
+		t.Skip("TEST_DB_NAME is not set, skipping database integration test")
+	}
+
+	cfg := config.Load().Database
+	cfg.DBName = dbName
+	cfg.AutoMigrate = true
+
+	db, err := database.New(&cfg, logrus.New())
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	// Тестовые коды ISO 4217, чтобы не пересекаться с запросами по настоящим парам
+	const clients = 50
+	handler := &Handler{
+		db:         db,
+		logger:     logrus.New(),
+		currencies: currency.NewStatic("XTS", "XXX"),
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	statuses := make([]int, clients)
+	ids := make([]string, clients)
+
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			req := httptest.NewRequest("POST", "/quotes/update", strings.NewReader(`{"from": "xts", "to": "XXX"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.UpdateQuote(rr, req)
+
+			statuses[i] = rr.Code
+			var response models.UpdateQuoteResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err == nil {
+				ids[i] = response.ID
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	for i := 0; i < clients; i++ {
+		assert.Equal(t, http.StatusOK, statuses[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+	if !assert.NotEmpty(t, ids[0]) {
+		return
+	}
+
+	// Завершаем запрос, чтобы следующий запуск теста создал новый pending запрос
+	assert.NoError(t, db.UpdateQuoteRequestStatus(ids[0], "completed"))
+}
+
+// Триггер воркера, публикующий смену статуса запроса в шину
+type publishingTrigger struct {
+	hub       *pubsub.Hub
+	requestID string
+	calls     int
+}
+
+func (t *publishingTrigger) Trigger() {
+	t.calls++
+	t.hub.Publish(pubsub.RequestTopic(t.requestID), models.QuoteRequestStatusResponse{ID: t.requestID})
+}
+
+func TestUpdateQuote_Wait(t *testing.T) {
+	pending := &models.QuoteRequest{ID: "123", From: "EUR", To: "USD", Status: "pending"}
+	historyID := int64(7)
+
 	tests := []struct {
 		name           string
-		requestID      string
+		wait           string
 		mockSetup      func(*MockDB)
+		expectTrigger  bool
 		expectedStatus int
 	}{
 		{
-			name:      "Valid request",
-			requestID: "123",
+			name: "Completed before timeout",
+			wait: "5s",
 			mockSetup: func(mockDB *MockDB) {
-				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
-					ID:     "123",
-					From:   "EUR",
-					To:     "USD",
-					Status: "completed",
-				}, nil)
-				mockDB.On("GetQuote", "EUR", "USD").Return(&models.Quote{
-					ID:   "456",
-					From: "EUR",
-					To:   "USD",
-					Rate: 1.1,
-				}, nil)
+				mockDB.On("GetQuoteRequest", "123").Return(pending, nil).Once()
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{ID: "123", From: "EUR", To: "USD", Status: "completed", QuoteHistoryID: &historyID}, nil)
+				// Ответ строится из записи истории запроса, а не из последней котировки пары
+				mockDB.On("GetQuoteHistoryEntry", historyID).Return(&models.QuoteHistoryEntry{ID: historyID, From: "EUR", To: "USD", Rate: dec("1.1")}, nil)
 			},
+			expectTrigger:  true,
 			expectedStatus: http.StatusOK,
 		},
 		{
-			name:      "Request not found",
-			requestID: "999",
+			name: "Request dead",
+			wait: "5s",
 			mockSetup: func(mockDB *MockDB) {
-				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), assert.AnError)
+				mockDB.On("GetQuoteRequest", "123").Return(pending, nil).Once()
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{ID: "123", Status: "dead", Attempts: 5, FailureReason: models.FailureReasonUpstreamUnavailable}, nil)
 			},
-			expectedStatus: http.StatusNotFound,
+			expectTrigger:  true,
+			expectedStatus: http.StatusConflict,
 		},
 		{
-			name:      "Request not completed",
-			requestID: "123",
+			name: "Timeout",
+			wait: "50ms",
 			mockSetup: func(mockDB *MockDB) {
-				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
-					ID:     "123",
-					From:   "EUR",
-					To:     "USD",
-					Status: "pending",
-				}, nil)
+				mockDB.On("GetQuoteRequest", "123").Return(pending, nil)
 			},
+			expectTrigger:  true,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Invalid wait",
+			wait:           "soon",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Wait too long",
+			wait:           "1m",
+			mockSetup:      func(mockDB *MockDB) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 	}
@@ -249,92 +630,2040 @@ func TestGetQuoteByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockDB := new(MockDB)
 			tt.mockSetup(mockDB)
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockDB.On("CreateOrGetPendingQuoteRequest", "EUR", "USD").Return(pending, nil)
+			}
 
-			logger := logrus.New()
+			hub := pubsub.New()
+			trigger := &publishingTrigger{hub: hub, requestID: "123"}
 			handler := &Handler{
-				db:                  mockDB,
-				logger:              logger,
-				supportedCurrencies: []string{"USD", "EUR", "MXN"},
+				db:            mockDB,
+				logger:        logrus.New(),
+				currencies:    currency.NewStatic("USD", "EUR", "MXN"),
+				hub:           hub,
+				updateTrigger: trigger,
 			}
 
-			req := httptest.NewRequest("GET", "/quotes/"+tt.requestID, nil)
-			req = mux.SetURLVars(req, map[string]string{"id": tt.requestID})
-
+			req := httptest.NewRequest("POST", "/quotes/update?wait="+tt.wait, bytes.NewBufferString(`{"from": "EUR", "to": "USD"}`))
 			rr := httptest.NewRecorder()
-			handler.GetQuoteByID(rr, req)
+			handler.UpdateQuote(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectTrigger, trigger.calls == 1)
+			assert.Equal(t, 0, hub.SubscriberCount())
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				var response models.QuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "1.1", response.Rate.String())
+				// Курс передаётся строкой, чтобы клиенты не теряли точность
+				assert.Contains(t, rr.Body.String(), `"rate":"1.1"`)
+			case http.StatusAccepted:
+				var response models.UpdateQuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "123", response.ID)
+				assert.Equal(t, "pending", response.Status)
+				assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+			}
+
 			mockDB.AssertExpectations(t)
 		})
 	}
 }
 
-func TestGetLatestQuote(t *testing.T) {
+func TestGetQuoteByID(t *testing.T) {
+	historyID := int64(42)
+	recordedAt := time.Date(2025, 9, 28, 10, 30, 4, 0, time.UTC)
+
 	tests := []struct {
 		name           string
-		from           string
-		to             string
+		requestID      string
 		mockSetup      func(*MockDB)
 		expectedStatus int
+		expectedRate   string
 	}{
 		{
-			name: "Valid request",
-			from: "EUR",
-			to:   "USD",
+			name:      "Valid request",
+			requestID: "123",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:             "123",
+					From:           "EUR",
+					To:             "USD",
+					Status:         "completed",
+					QuoteHistoryID: &historyID,
+				}, nil)
+				// Пара могла обновиться после запроса: возвращается курс, которым выполнен именно он
+				mockDB.On("GetQuoteHistoryEntry", historyID).Return(&models.QuoteHistoryEntry{
+					ID:         historyID,
+					From:       "EUR",
+					To:         "USD",
+					Rate:       dec("1.1"),
+					RecordedAt: recordedAt,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedRate:   "1.1",
+		},
+		{
+			name:      "Request completed before quote history link",
+			requestID: "124",
 			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "124").Return(&models.QuoteRequest{
+					ID:     "124",
+					From:   "EUR",
+					To:     "USD",
+					Status: "completed",
+				}, nil)
 				mockDB.On("GetQuote", "EUR", "USD").Return(&models.Quote{
 					ID:   "456",
 					From: "EUR",
 					To:   "USD",
-					Rate: 1.1,
+					Rate: dec("1.2"),
 				}, nil)
 			},
 			expectedStatus: http.StatusOK,
+			expectedRate:   "1.2",
 		},
 		{
-			name: "Quote not found",
-			from: "EUR",
-			to:   "MXN",
+			name:      "Linked quote history entry unavailable",
+			requestID: "123",
 			mockSetup: func(mockDB *MockDB) {
-				mockDB.On("GetQuote", "EUR", "MXN").Return((*models.Quote)(nil), assert.AnError)
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:             "123",
+					From:           "EUR",
+					To:             "USD",
+					Status:         "completed",
+					QuoteHistoryID: &historyID,
+				}, nil)
+				mockDB.On("GetQuoteHistoryEntry", historyID).Return((*models.QuoteHistoryEntry)(nil), assert.AnError)
 			},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusInternalServerError,
 		},
 		{
-			name: "Empty from currency",
-			from: "",
-			to:   "USD",
+			name:      "Request not found",
+			requestID: "999",
 			mockSetup: func(mockDB *MockDB) {
-				// No mock setup needed for validation error
+				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), database.ErrNotFound)
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Database unavailable",
+			requestID: "999",
+			mockSetup: func(mockDB *MockDB) {
+				// Сбой базы данных не выдаётся за отсутствие запроса
+				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), fmt.Errorf("failed to get quote request: %w", assert.AnError))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:      "Request not completed",
+			requestID: "123",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:     "123",
+					From:   "EUR",
+					To:     "USD",
+					Status: "pending",
+				}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:      "Request dead",
+			requestID: "125",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "125").Return(&models.QuoteRequest{
+					ID:            "125",
+					From:          "EUR",
+					To:            "USD",
+					Status:        "dead",
+					Attempts:      5,
+					FailureReason: models.FailureReasonUpstreamUnavailable,
+				}, nil)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:      "Valid UUIDv7 request",
+			requestID: "01912d68-783e-7a1b-8f3c-4d5e6f708192",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "01912d68-783e-7a1b-8f3c-4d5e6f708192").Return(&models.QuoteRequest{
+					ID:     "01912d68-783e-7a1b-8f3c-4d5e6f708192",
+					From:   "EUR",
+					To:     "USD",
+					Status: "pending",
+				}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Malformed ID",
+			requestID:      "not-a-valid-id",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Empty ID",
+			requestID:      "",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			logger := logrus.New()
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logger,
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/"+tt.requestID, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.requestID})
+
+			rr := httptest.NewRecorder()
+			handler.GetQuoteByID(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusAccepted {
+				assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+			}
+			if tt.expectedRate != "" {
+				var response models.QuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.requestID, response.ID)
+				assert.Equal(t, tt.expectedRate, response.Rate.String())
+			}
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetQuoteRequestStatus(t *testing.T) {
+	nextAttemptAt := time.Now().Add(time.Minute)
+	completedAt := time.Date(2025, 9, 28, 10, 30, 5, 0, time.UTC)
+	recordedAt := time.Date(2025, 9, 28, 10, 30, 4, 0, time.UTC)
+	historyID := int64(42)
+
+	tests := []struct {
+		name               string
+		requestID          string
+		mockSetup          func(*MockDB)
+		expectedStatus     int
+		expectedRetryAfter string
+		expectedReason     string
+		expectQuote        bool
+	}{
+		{
+			name:      "Completed request with linked quote",
+			requestID: "123",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+					ID:             "123",
+					From:           "EUR",
+					To:             "MXN",
+					Status:         "completed",
+					CompletedAt:    &completedAt,
+					QuoteHistoryID: &historyID,
+				}, nil)
+				mockDB.On("GetQuoteHistoryEntry", historyID).Return(&models.QuoteHistoryEntry{
+					ID:         historyID,
+					From:       "EUR",
+					To:         "MXN",
+					Rate:       dec("21.6"),
+					RecordedAt: recordedAt,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectQuote:    true,
+		},
+		{
+			name:      "Pending request",
+			requestID: "124",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "124").Return(&models.QuoteRequest{
+					ID:     "124",
+					From:   "EUR",
+					To:     "MXN",
+					Status: "pending",
+				}, nil)
+			},
+			expectedStatus:     http.StatusAccepted,
+			expectedRetryAfter: "30",
+		},
+		{
+			name:      "Failed request waiting for retry",
+			requestID: "125",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "125").Return(&models.QuoteRequest{
+					ID:            "125",
+					From:          "EUR",
+					To:            "MXN",
+					Status:        "failed",
+					Attempts:      2,
+					NextAttemptAt: &nextAttemptAt,
+					FailureReason: models.FailureReasonUpstreamUnavailable,
+					LastError:     "all rate providers failed",
+				}, nil)
+			},
+			expectedStatus:     http.StatusAccepted,
+			expectedRetryAfter: "60",
+			expectedReason:     models.FailureReasonUpstreamUnavailable,
+		},
+		{
+			name:      "Dead request",
+			requestID: "126",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "126").Return(&models.QuoteRequest{
+					ID:            "126",
+					From:          "EUR",
+					To:            "MXN",
+					Status:        "dead",
+					Attempts:      5,
+					FailureReason: models.FailureReasonCurrencyNotInRates,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedReason: models.FailureReasonCurrencyNotInRates,
+		},
+		{
+			name:      "Request not found",
+			requestID: "999",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Database unavailable",
+			requestID: "999",
+			mockSetup: func(mockDB *MockDB) {
+				// Сбой базы данных не выдаётся за отсутствие запроса
+				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), fmt.Errorf("failed to get quote request: %w", assert.AnError))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:           mockDB,
+				logger:       logrus.New(),
+				currencies:   currency.NewStatic("USD", "EUR", "MXN"),
+				pollInterval: 30 * time.Second,
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/requests/"+tt.requestID, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.requestID})
+
+			rr := httptest.NewRecorder()
+			handler.GetQuoteRequestStatus(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedRetryAfter, rr.Header().Get("Retry-After"))
+
+			if tt.expectedStatus == http.StatusOK || tt.expectedStatus == http.StatusAccepted {
+				var response models.QuoteRequestStatusResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.requestID, response.ID)
+				assert.Equal(t, tt.expectedReason, response.FailureReason)
+
+				if tt.expectQuote {
+					if assert.NotNil(t, response.Quote) {
+						assert.Equal(t, "21.6", response.Quote.Rate.String())
+						assert.True(t, recordedAt.Equal(response.Quote.RecordedAt))
+					}
+					assert.NotNil(t, response.CompletedAt)
+				} else {
+					assert.Nil(t, response.Quote)
+				}
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestID      string
+		clientID       string
+		authorization  string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:          "Deliveries for client",
+			requestID:     "123",
+			clientID:      "acme",
+			authorization: "Bearer s3cret",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{ID: "123", Status: "completed"}, nil)
+				mockDB.On("GetWebhookDeliveries", "123", "acme").Return([]*models.WebhookDelivery{
+					{ID: 2, RequestID: "123", ClientID: "acme", Event: "quote_request.completed", Status: models.WebhookDelivered, Attempts: 1},
+					{ID: 1, RequestID: "123", ClientID: "acme", Event: "quote_request.failed", Status: models.WebhookFailed, Attempts: 8},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:          "No deliveries",
+			requestID:     "123",
+			clientID:      "acme",
+			authorization: "Bearer s3cret",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{ID: "123", Status: "pending"}, nil)
+				mockDB.On("GetWebhookDeliveries", "123", "acme").Return([]*models.WebhookDelivery(nil), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing client ID",
+			requestID:      "123",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing credential",
+			requestID:      "123",
+			clientID:       "acme",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Secret of another client",
+			requestID:      "123",
+			clientID:       "acme",
+			authorization:  "Bearer other-secret",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unknown client",
+			requestID:      "123",
+			clientID:       "stranger",
+			authorization:  "Bearer s3cret",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "Request not found",
+			requestID:     "999",
+			clientID:      "acme",
+			authorization: "Bearer s3cret",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteRequest", "999").Return((*models.QuoteRequest)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:             mockDB,
+				logger:         logrus.New(),
+				webhookSecrets: map[string]string{"acme": "s3cret", "globex": "other-secret"},
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/requests/"+tt.requestID+"/webhooks", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.requestID})
+			if tt.clientID != "" {
+				req.Header.Set("X-Client-ID", tt.clientID)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.GetWebhookDeliveries(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.WebhookDeliveriesResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.requestID, response.RequestID)
+				assert.NotNil(t, response.Deliveries)
+				assert.Len(t, response.Deliveries, tt.expectedCount)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetWatchlist(t *testing.T) {
+	mockDB := new(MockDB)
+	mockDB.On("GetWatchlist").Return([]*models.WatchlistEntry{
+		{ID: 1, From: "EUR", To: "USD", Schedule: "@every 5m0s", Enabled: true},
+		{ID: 2, From: "USD", To: "MXN", Schedule: "0 * * * *", Enabled: false},
+	}, nil)
+
+	handler := &Handler{
+		db:     mockDB,
+		logger: logrus.New(),
+	}
+
+	req := httptest.NewRequest("GET", "/watchlist", nil)
+	rr := httptest.NewRecorder()
+	handler.GetWatchlist(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.WatchlistResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Entries, 2)
+	assert.Equal(t, "0 * * * *", response.Entries[1].Schedule)
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestCreateWatchlistEntry(t *testing.T) {
+	tests := []struct {
+		name             string
+		requestBody      string
+		mockSetup        func(*MockDB)
+		expectedStatus   int
+		expectedSchedule string
+	}{
+		{
+			name:        "Interval",
+			requestBody: `{"from": "eur", "to": "usd", "interval": "5m"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateWatchlistEntry", "EUR", "USD", "@every 5m0s", true, mock.AnythingOfType("time.Time")).
+					Return(&models.WatchlistEntry{ID: 1, From: "EUR", To: "USD", Schedule: "@every 5m0s", Enabled: true}, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedSchedule: "@every 5m0s",
+		},
+		{
+			name:        "Cron schedule disabled",
+			requestBody: `{"from": "USD", "to": "MXN", "schedule": "*/15 9-18 * * 1-5", "enabled": false}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateWatchlistEntry", "USD", "MXN", "*/15 9-18 * * 1-5", false, mock.AnythingOfType("time.Time")).
+					Return(&models.WatchlistEntry{ID: 2, From: "USD", To: "MXN", Schedule: "*/15 9-18 * * 1-5"}, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedSchedule: "*/15 9-18 * * 1-5",
+		},
+		{
+			name:        "Pair already watched",
+			requestBody: `{"from": "EUR", "to": "USD", "interval": "1m"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateWatchlistEntry", "EUR", "USD", "@every 1m0s", true, mock.AnythingOfType("time.Time")).
+					Return((*models.WatchlistEntry)(nil), database.ErrWatchlistEntryExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Both interval and schedule",
+			requestBody:    `{"from": "EUR", "to": "USD", "interval": "5m", "schedule": "* * * * *"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No schedule",
+			requestBody:    `{"from": "EUR", "to": "USD"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid cron",
+			requestBody:    `{"from": "EUR", "to": "USD", "schedule": "61 * * * *"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Schedule never fires",
+			requestBody:    `{"from": "EUR", "to": "USD", "schedule": "0 0 31 2 *"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported currency",
+			requestBody:    `{"from": "EUR", "to": "XXX", "interval": "5m"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("POST", "/watchlist", bytes.NewBufferString(tt.requestBody))
+			rr := httptest.NewRecorder()
+			handler.CreateWatchlistEntry(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response models.WatchlistEntry
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedSchedule, response.Schedule)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateWatchlistEntry(t *testing.T) {
+	nextRunAt := time.Date(2025, 9, 28, 12, 0, 0, 0, time.UTC)
+	existing := func() *models.WatchlistEntry {
+		return &models.WatchlistEntry{ID: 1, From: "EUR", To: "USD", Schedule: "@every 5m0s", Enabled: true, NextRunAt: nextRunAt}
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		requestBody    string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+	}{
+		{
+			name:        "Change schedule",
+			id:          "1",
+			requestBody: `{"schedule": "0 * * * *"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(1)).Return(existing(), nil)
+				mockDB.On("UpdateWatchlistEntry", int64(1), "0 * * * *", true, mock.MatchedBy(func(next time.Time) bool {
+					return next.After(time.Now()) && next.Minute() == 0
+				})).Return(&models.WatchlistEntry{ID: 1, Schedule: "0 * * * *", Enabled: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Disable keeps schedule",
+			id:          "1",
+			requestBody: `{"enabled": false}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(1)).Return(existing(), nil)
+				mockDB.On("UpdateWatchlistEntry", int64(1), "@every 5m0s", false, nextRunAt).
+					Return(&models.WatchlistEntry{ID: 1, Schedule: "@every 5m0s"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Pair cannot be changed",
+			id:          "1",
+			requestBody: `{"from": "USD", "interval": "1m"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(1)).Return(existing(), nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "abc",
+			requestBody:    `{"enabled": false}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Entry not found",
+			id:          "99",
+			requestBody: `{"enabled": false}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(99)).Return((*models.WatchlistEntry)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:     mockDB,
+				logger: logrus.New(),
+			}
+
+			req := httptest.NewRequest("PUT", "/watchlist/"+tt.id, bytes.NewBufferString(tt.requestBody))
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			rr := httptest.NewRecorder()
+			handler.UpdateWatchlistEntry(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteWatchlistEntry(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+	}{
+		{
+			name: "Deleted",
+			id:   "1",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(1)).Return(&models.WatchlistEntry{ID: 1, From: "EUR", To: "USD"}, nil)
+				mockDB.On("DeleteWatchlistEntry", int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Entry not found",
+			id:   "2",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetWatchlistEntry", int64(2)).Return((*models.WatchlistEntry)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:     mockDB,
+				logger: logrus.New(),
+			}
+
+			req := httptest.NewRequest("DELETE", "/watchlist/"+tt.id, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+			rr := httptest.NewRecorder()
+			handler.DeleteWatchlistEntry(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLatestQuote(t *testing.T) {
+	tests := []struct {
+		name           string
+		from           string
+		to             string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+	}{
+		{
+			name: "Valid request",
+			from: "EUR",
+			to:   "USD",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuote", "EUR", "USD").Return(&models.Quote{
+					ID:   "456",
+					From: "EUR",
+					To:   "USD",
+					Rate: dec("1.1"),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Quote not found",
+			from: "EUR",
+			to:   "MXN",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuote", "EUR", "MXN").Return((*models.Quote)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Empty from currency",
+			from: "",
+			to:   "USD",
+			mockSetup: func(mockDB *MockDB) {
+				// No mock setup needed for validation error
+			},
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name: "Empty to currency",
 			from: "EUR",
 			to:   "",
 			mockSetup: func(mockDB *MockDB) {
-				// No mock setup needed for validation error
+				// No mock setup needed for validation error
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unsupported from currency",
+			from: "GBP",
+			to:   "USD",
+			mockSetup: func(mockDB *MockDB) {
+				// No mock setup needed for validation error
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unsupported to currency",
+			from: "USD",
+			to:   "GBP",
+			mockSetup: func(mockDB *MockDB) {
+				// No mock setup needed for validation error
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			logger := logrus.New()
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logger,
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/latest?from="+tt.from+"&to="+tt.to, nil)
+
+			rr := httptest.NewRecorder()
+			handler.GetLatestQuote(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLatestQuote_Staleness(t *testing.T) {
+	quotesConfig := config.QuotesConfig{
+		MaxAge:      time.Hour,
+		PairMaxAge:  map[string]time.Duration{"USD/MXN": 5 * time.Minute},
+		StalePolicy: config.StalePolicyRefresh,
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		quoteAge        time.Duration
+		quotes          config.QuotesConfig
+		expectRefresh   bool
+		expectedStatus  int
+		expectFreshness bool
+		expectedStale   bool
+	}{
+		{
+			name:            "Fresh quote",
+			query:           "from=EUR&to=USD",
+			quoteAge:        10 * time.Minute,
+			quotes:          quotesConfig,
+			expectedStatus:  http.StatusOK,
+			expectFreshness: true,
+		},
+		{
+			name:            "Stale quote is served and refreshed",
+			query:           "from=EUR&to=USD",
+			quoteAge:        2 * time.Hour,
+			quotes:          quotesConfig,
+			expectRefresh:   true,
+			expectedStatus:  http.StatusOK,
+			expectFreshness: true,
+			expectedStale:   true,
+		},
+		{
+			name:            "Per-pair max age",
+			query:           "from=USD&to=MXN",
+			quoteAge:        10 * time.Minute,
+			quotes:          quotesConfig,
+			expectRefresh:   true,
+			expectedStatus:  http.StatusOK,
+			expectFreshness: true,
+			expectedStale:   true,
+		},
+		{
+			name:            "Max age in seconds",
+			query:           "from=EUR&to=USD&max_age=60",
+			quoteAge:        2 * time.Minute,
+			quotes:          quotesConfig,
+			expectRefresh:   true,
+			expectedStatus:  http.StatusOK,
+			expectFreshness: true,
+			expectedStale:   true,
+		},
+		{
+			name:           "Stale quote rejected",
+			query:          "from=EUR&to=USD&max_age=1m&on_stale=reject",
+			quoteAge:       2 * time.Minute,
+			quotes:         quotesConfig,
+			expectRefresh:  true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Reject policy from config",
+			query:          "from=EUR&to=USD",
+			quoteAge:       2 * time.Hour,
+			quotes:         config.QuotesConfig{MaxAge: time.Hour, StalePolicy: config.StalePolicyReject},
+			expectRefresh:  true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "No max age configured",
+			query:          "from=EUR&to=USD",
+			quoteAge:       48 * time.Hour,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid max_age",
+			query:          "from=EUR&to=USD&max_age=soon",
+			quotes:         quotesConfig,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid on_stale",
+			query:          "from=EUR&to=USD&on_stale=ignore",
+			quotes:         quotesConfig,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			if tt.expectedStatus != http.StatusBadRequest {
+				mockDB.On("GetQuote", mock.Anything, mock.Anything).Return(&models.Quote{
+					ID:        "456",
+					Rate:      dec("1.1"),
+					UpdatedAt: time.Now().Add(-tt.quoteAge),
+				}, nil)
+			}
+			if tt.expectRefresh {
+				mockDB.On("CreateOrGetPendingQuoteRequest", mock.Anything, mock.Anything).
+					Return(&models.QuoteRequest{ID: "refresh-1", Status: "pending"}, nil)
+			}
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				quotes:     tt.quotes,
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/latest?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetLatestQuote(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				var response models.QuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				if !tt.expectFreshness {
+					assert.Nil(t, response.Freshness)
+					break
+				}
+				assert.NotNil(t, response.Freshness)
+				assert.Equal(t, tt.expectedStale, response.Freshness.Stale)
+				if tt.expectedStale {
+					assert.Equal(t, "refresh-1", response.Freshness.RefreshRequestID)
+				}
+			case http.StatusServiceUnavailable:
+				var response models.StaleQuoteErrorResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "Stale quote", response.Error)
+				assert.True(t, response.Quote.Freshness.Stale)
+				assert.Equal(t, "refresh-1", response.Quote.Freshness.RefreshRequestID)
+				assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLatestQuote_Pricing(t *testing.T) {
+	pricing := config.PricingConfig{
+		SpreadBps:     decimal.NewFromInt(10),
+		PairSpreadBps: map[string]decimal.Decimal{"EUR/MXN": decimal.NewFromInt(30)},
+		TierMarkupBps: map[string]decimal.Decimal{"retail": decimal.NewFromInt(50), "vip": decimal.NewFromInt(5)},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		quote          *models.Quote
+		expectedStatus int
+		expectedBid    string
+		expectedAsk    string
+		expectedTier   string
+	}{
+		{
+			name:           "Pair spread without tier",
+			query:          "from=EUR&to=MXN",
+			quote:          &models.Quote{ID: "1", From: "EUR", To: "MXN", Rate: dec("20")},
+			expectedStatus: http.StatusOK,
+			expectedBid:    "19.97",
+			expectedAsk:    "20.03",
+		},
+		{
+			name:           "Retail tier markup",
+			query:          "from=EUR&to=MXN&tier=RETAIL",
+			quote:          &models.Quote{ID: "1", From: "EUR", To: "MXN", Rate: dec("20")},
+			expectedStatus: http.StatusOK,
+			expectedBid:    "19.87",
+			expectedAsk:    "20.13",
+			expectedTier:   "retail",
+		},
+		{
+			name:           "VIP tier markup",
+			query:          "from=EUR&to=MXN&tier=vip",
+			quote:          &models.Quote{ID: "1", From: "EUR", To: "MXN", Rate: dec("20")},
+			expectedStatus: http.StatusOK,
+			expectedBid:    "19.96",
+			expectedAsk:    "20.04",
+			expectedTier:   "vip",
+		},
+		{
+			name:           "Default spread",
+			query:          "from=USD&to=EUR",
+			quote:          &models.Quote{ID: "2", From: "USD", To: "EUR", Rate: dec("0.85")},
+			expectedStatus: http.StatusOK,
+			expectedBid:    "0.849575",
+			expectedAsk:    "0.850425",
+		},
+		{
+			name:           "Unknown tier",
+			query:          "from=EUR&to=MXN&tier=gold",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			if tt.quote != nil {
+				tt.quote.UpdatedAt = time.Now()
+				mockDB.On("GetQuote", tt.quote.From, tt.quote.To).Return(tt.quote, nil)
+			}
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				quotes:     config.QuotesConfig{RatePrecision: 8},
+				pricing:    pricing,
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/latest?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetLatestQuote(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response models.QuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				if assert.NotNil(t, response.Bid) && assert.NotNil(t, response.Ask) && assert.NotNil(t, response.Mid) {
+					assert.Equal(t, tt.expectedBid, response.Bid.String())
+					assert.Equal(t, tt.expectedAsk, response.Ask.String())
+					assert.Equal(t, tt.quote.Rate.String(), response.Mid.String())
+				}
+				assert.Equal(t, tt.expectedTier, response.Tier)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBatchUpdateQuotes(t *testing.T) {
+	tests := []struct {
+		name            string
+		requestBody     string
+		mockSetup       func(*MockDB)
+		expectedStatus  int
+		expectedResults []string // ID запроса или ошибка для каждой пары
+	}{
+		{
+			name:        "Mixed results",
+			requestBody: `{"pairs": [{"from": "EUR", "to": "USD"}, {"from": "usd", "to": "mxn"}, {"from": "EUR", "to": "GBP"}, {"from": "EUR", "to": "USD"}, {"from": "MXN", "to": "EUR"}]}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateOrGetPendingQuoteRequest", "EUR", "USD").Return(&models.QuoteRequest{ID: "1", From: "EUR", To: "USD", Status: "pending"}, nil)
+				mockDB.On("CreateOrGetPendingQuoteRequest", "USD", "MXN").Return(&models.QuoteRequest{ID: "2", From: "USD", To: "MXN", Status: "pending"}, nil)
+				mockDB.On("CreateOrGetPendingQuoteRequest", "MXN", "EUR").Return((*models.QuoteRequest)(nil), assert.AnError)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedResults: []string{"1", "2", "Validation error", "Internal error"},
+		},
+		{
+			name:           "Empty pairs",
+			requestBody:    `{"pairs": []}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			requestBody:    `{"pairs": `,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Too many pairs",
+			requestBody:    `{"pairs": [` + strings.TrimSuffix(strings.Repeat(`{"from": "EUR", "to": "USD"},`, maxBatchPairs+1), ",") + `]}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("POST", "/quotes/update/batch", bytes.NewBufferString(tt.requestBody))
+			rr := httptest.NewRecorder()
+			handler.BatchUpdateQuotes(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.BatchUpdateQuoteResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Results, len(tt.expectedResults))
+				for i, result := range response.Results {
+					if result.Request != nil {
+						assert.Equal(t, tt.expectedResults[i], result.Request.ID)
+					} else {
+						assert.Equal(t, tt.expectedResults[i], result.Error.Error)
+					}
+				}
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLatestQuotesBatch(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		query           string
+		mockSetup       func(*MockDB)
+		quotes          config.QuotesConfig
+		expectedStatus  int
+		expectedResults map[string]string // курс или ошибка по паре
+	}{
+		{
+			name:  "Quotes and per-pair errors",
+			query: "pairs=EUR/USD,usd/mxn,EUR/GBP,MXN/EUR,EUR/USD",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuotes", []models.CurrencyPair{{From: "EUR", To: "USD"}, {From: "USD", To: "MXN"}, {From: "MXN", To: "EUR"}}).Return([]*models.Quote{
+					{ID: "1", From: "EUR", To: "USD", Rate: dec("1.1"), UpdatedAt: now},
+					{ID: "2", From: "USD", To: "MXN", Rate: dec("18.5"), UpdatedAt: now},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResults: map[string]string{
+				"EUR/USD": "1.1",
+				"USD/MXN": "18.5",
+				"EUR/GBP": "Validation error",
+				"MXN/EUR": "Not found",
+			},
+		},
+		{
+			name:  "Stale quote rejected",
+			query: "pairs=EUR/USD,USD/MXN&on_stale=reject",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuotes", mock.Anything).Return([]*models.Quote{
+					{ID: "1", From: "EUR", To: "USD", Rate: dec("1.1"), UpdatedAt: now},
+					{ID: "2", From: "USD", To: "MXN", Rate: dec("18.5"), UpdatedAt: now.Add(-2 * time.Hour)},
+				}, nil)
+				mockDB.On("CreateOrGetPendingQuoteRequest", "USD", "MXN").Return(&models.QuoteRequest{ID: "3", Status: "pending"}, nil)
+			},
+			quotes:         config.QuotesConfig{MaxAge: time.Hour},
+			expectedStatus: http.StatusOK,
+			expectedResults: map[string]string{
+				"EUR/USD": "1.1",
+				"USD/MXN": "Stale quote",
+			},
+		},
+		{
+			name:           "Missing pairs",
+			query:          "",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid max_age",
+			query:          "pairs=EUR/USD&max_age=-1",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Database error",
+			query: "pairs=EUR/USD",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuotes", mock.Anything).Return([]*models.Quote(nil), assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				quotes:     tt.quotes,
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/latest/batch?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetLatestQuotesBatch(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.BatchLatestQuotesResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Results, len(tt.expectedResults))
+				for _, result := range response.Results {
+					if result.Quote != nil {
+						assert.Equal(t, tt.expectedResults[result.Pair], result.Quote.Rate.String())
+					} else {
+						assert.Equal(t, tt.expectedResults[result.Pair], result.Error.Error)
+					}
+				}
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+// Триггер воркера, считающий вызовы
+type countingTrigger struct {
+	calls int
+}
+
+func (t *countingTrigger) Trigger() {
+	t.calls++
+}
+
+func TestGetQuoteMatrix(t *testing.T) {
+	fetchedAt := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	snapshot := &models.RatesSnapshot{
+		ID: 7,
+		Rates: &models.ExchangeRates{
+			Provider: "fxratesapi",
+			Rates:    map[string]decimal.Decimal{"USD": dec("1"), "EUR": dec("0.8"), "MXN": dec("20")},
+		},
+		FetchedAt: fetchedAt,
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		snapshot           *models.RatesSnapshot
+		expectedStatus     int
+		expectedCurrencies []string
+		expectedRates      [][]string
+		expectedMissing    []string
+		expectTrigger      bool
+		expectStale        bool
+	}{
+		{
+			name:               "All enabled currencies in alphabetical order",
+			snapshot:           snapshot,
+			expectedStatus:     http.StatusOK,
+			expectedCurrencies: []string{"EUR", "MXN", "USD"},
+			expectedRates: [][]string{
+				{"1", "25", "1.25"},
+				{"0.04", "1", "0.05"},
+				{"0.8", "20", "1"},
+			},
+		},
+		{
+			name:               "Selected currencies",
+			query:              "currencies=mxn,EUR,MXN",
+			snapshot:           snapshot,
+			expectedStatus:     http.StatusOK,
+			expectedCurrencies: []string{"MXN", "EUR"},
+			expectedRates: [][]string{
+				{"1", "0.04"},
+				{"25", "1"},
+			},
+		},
+		{
+			name:           "Unsupported currency",
+			query:          "currencies=USD,GBP",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No snapshot yet",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectTrigger:  true,
+		},
+		{
+			name:               "Stale snapshot returned with flag",
+			query:              "currencies=USD,EUR&max_age=1h",
+			snapshot:           snapshot,
+			expectedStatus:     http.StatusOK,
+			expectedCurrencies: []string{"USD", "EUR"},
+			expectedRates: [][]string{
+				{"1", "0.8"},
+				{"1.25", "1"},
+			},
+			expectTrigger: true,
+			expectStale:   true,
+		},
+		{
+			name:           "Stale snapshot rejected",
+			query:          "max_age=1h&on_stale=reject",
+			snapshot:       snapshot,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectTrigger:  true,
+		},
+		{
+			name:           "Invalid max_age",
+			query:          "max_age=soon",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Currency missing in snapshot",
+			query: "currencies=USD,MXN,EUR",
+			snapshot: &models.RatesSnapshot{
+				ID:        7,
+				Rates:     &models.ExchangeRates{Provider: "fxratesapi", Rates: map[string]decimal.Decimal{"USD": dec("1"), "EUR": dec("0.8")}},
+				FetchedAt: fetchedAt,
+			},
+			expectedStatus:     http.StatusOK,
+			expectedCurrencies: []string{"USD", "MXN", "EUR"},
+			expectedRates: [][]string{
+				{"1", "null", "0.8"},
+				{"null", "null", "null"},
+				{"1.25", "null", "1"},
+			},
+			expectedMissing: []string{"MXN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			if tt.expectedStatus != http.StatusBadRequest {
+				if tt.snapshot != nil {
+					mockDB.On("GetLatestRateSnapshot").Return(tt.snapshot, nil)
+				} else {
+					mockDB.On("GetLatestRateSnapshot").Return((*models.RatesSnapshot)(nil), fmt.Errorf("rate snapshot not found"))
+				}
+			}
+
+			trigger := &countingTrigger{}
+			handler := &Handler{
+				db:            mockDB,
+				logger:        logrus.New(),
+				currencies:    currency.NewStatic("USD", "EUR", "MXN"),
+				quotes:        config.QuotesConfig{RatePrecision: 8},
+				updateTrigger: trigger,
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/matrix?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.GetQuoteMatrix(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectTrigger, trigger.calls > 0)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.QuoteMatrixResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCurrencies, response.Currencies)
+				assert.Equal(t, tt.expectedMissing, response.Missing)
+				assert.Equal(t, "fxratesapi", response.Provider)
+				assert.True(t, fetchedAt.Equal(response.AsOf))
+				assert.Equal(t, int64(7), response.SnapshotID)
+				if tt.expectStale {
+					if assert.NotNil(t, response.Freshness) {
+						assert.True(t, response.Freshness.Stale)
+						assert.Equal(t, int64(3600), response.Freshness.MaxAgeSeconds)
+					}
+				} else {
+					assert.Nil(t, response.Freshness)
+				}
+				rates := make([][]string, len(response.Rates))
+				for i, row := range response.Rates {
+					for _, rate := range row {
+						if rate == nil {
+							rates[i] = append(rates[i], "null")
+							continue
+						}
+						rates[i] = append(rates[i], rate.String())
+					}
+				}
+				assert.Equal(t, tt.expectedRates, rates)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetRateSnapshot(t *testing.T) {
+	snapshot := &models.RatesSnapshot{
+		ID: 42,
+		Rates: &models.ExchangeRates{
+			Provider: "aggregate",
+			Date:     "2025-09-26",
+			Rates:    map[string]decimal.Decimal{"USD": dec("1"), "EUR": dec("0.8"), "MXN": dec("20")},
+			Sources:  map[string][]string{"EUR": {"ecb", "fxratesapi"}, "MXN": {"fxratesapi"}},
+		},
+		FetchedAt: time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		vars           map[string]string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+	}{
+		{
+			name: "Latest snapshot",
+			path: "/rates/snapshots/latest",
+			mockSetup: func(m *MockDB) {
+				m.On("GetLatestRateSnapshot").Return(snapshot, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "No snapshots yet",
+			path: "/rates/snapshots/latest",
+			mockSetup: func(m *MockDB) {
+				m.On("GetLatestRateSnapshot").Return((*models.RatesSnapshot)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Snapshot by ID",
+			path: "/rates/snapshots/42",
+			vars: map[string]string{"id": "42"},
+			mockSetup: func(m *MockDB) {
+				m.On("GetRateSnapshot", int64(42)).Return(snapshot, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid ID",
+			path:           "/rates/snapshots/abc",
+			vars:           map[string]string{"id": "abc"},
+			mockSetup:      func(m *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Snapshot not found",
+			path: "/rates/snapshots/5",
+			vars: map[string]string{"id": "5"},
+			mockSetup: func(m *MockDB) {
+				m.On("GetRateSnapshot", int64(5)).Return((*models.RatesSnapshot)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+			handler := &Handler{db: mockDB, logger: logrus.New()}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			if tt.vars != nil {
+				req = mux.SetURLVars(req, tt.vars)
+				handler.GetRateSnapshot(rr, req)
+			} else {
+				handler.GetLatestRateSnapshot(rr, req)
+			}
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.RateSnapshotResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(42), response.ID)
+				assert.Equal(t, "aggregate", response.Provider)
+				assert.Equal(t, "2025-09-26", response.UpstreamDate)
+				assert.Equal(t, snapshot.Rates.Rates, response.Rates)
+				assert.Equal(t, snapshot.Rates.Sources, response.Sources)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetQuoteHistory(t *testing.T) {
+	newest := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	entries := []*models.QuoteHistoryEntry{
+		{ID: 3, From: "EUR", To: "MXN", Rate: dec("21.7"), RecordedAt: newest},
+		{ID: 2, From: "EUR", To: "MXN", Rate: dec("21.6"), RecordedAt: newest.Add(-time.Minute)},
+		{ID: 1, From: "EUR", To: "MXN", Rate: dec("21.5"), RecordedAt: newest.Add(-2 * time.Minute)},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+		expectedItems  int
+		expectCursor   bool
+	}{
+		{
+			name:  "Full page without next cursor",
+			query: "from=EUR&to=MXN",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteHistory", mock.MatchedBy(func(f models.QuoteHistoryFilter) bool {
+					return f.From == "EUR" && f.To == "MXN" && f.Limit == defaultHistoryLimit+1 && f.After == nil
+				})).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedItems:  3,
+		},
+		{
+			name:  "Limited page returns next cursor",
+			query: "from=eur&to=mxn&limit=2",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteHistory", mock.MatchedBy(func(f models.QuoteHistoryFilter) bool {
+					return f.Limit == 3
+				})).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedItems:  2,
+			expectCursor:   true,
+		},
+		{
+			name:  "Cursor and time range are passed to database",
+			query: "from=EUR&to=MXN&since=2025-09-28T00:00:00Z&until=2025-09-29T00:00:00Z&cursor=" + encodeHistoryCursor(models.QuoteHistoryCursor{RecordedAt: newest, ID: 3}),
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteHistory", mock.MatchedBy(func(f models.QuoteHistoryFilter) bool {
+					return f.Since != nil && f.Until != nil && f.After != nil && f.After.ID == 3 && f.After.RecordedAt.Equal(newest)
+				})).Return(entries[1:], nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedItems:  2,
+		},
+		{
+			name:           "Invalid since",
+			query:          "from=EUR&to=MXN&since=yesterday",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Since after until",
+			query:          "from=EUR&to=MXN&since=2025-09-29T00:00:00Z&until=2025-09-28T00:00:00Z",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid limit",
+			query:          "from=EUR&to=MXN&limit=0",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid cursor",
+			query:          "from=EUR&to=MXN&cursor=???",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported currency",
+			query:          "from=GBP&to=MXN",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Database error",
+			query: "from=EUR&to=MXN",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteHistory", mock.Anything).Return([]*models.QuoteHistoryEntry(nil), assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			logger := logrus.New()
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logger,
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/history?"+tt.query, nil)
+
+			rr := httptest.NewRecorder()
+			handler.GetQuoteHistory(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.QuoteHistoryResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Items, tt.expectedItems)
+				assert.Equal(t, tt.expectCursor, response.NextCursor != "")
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetQuoteAt(t *testing.T) {
+	at := time.Date(2025, 9, 28, 12, 0, 0, 0, time.UTC)
+	recordedAt := at.Add(-90 * time.Second)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+		expectedAge    int64
+	}{
+		{
+			name:  "Valid request",
+			query: "from=EUR&to=MXN&ts=2025-09-28T12:00:00Z",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteAt", "EUR", "MXN", mock.MatchedBy(func(ts time.Time) bool {
+					return ts.Equal(at)
+				})).Return(&models.QuoteHistoryEntry{
+					ID:         7,
+					From:       "EUR",
+					To:         "MXN",
+					Rate:       dec("21.6"),
+					RecordedAt: recordedAt,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedAge:    90,
+		},
+		{
+			name:  "No quote before timestamp",
+			query: "from=EUR&to=MXN&ts=2025-09-28T12:00:00Z",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetQuoteAt", "EUR", "MXN", mock.Anything).Return((*models.QuoteHistoryEntry)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Missing timestamp",
+			query:          "from=EUR&to=MXN",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid timestamp",
+			query:          "from=EUR&to=MXN&ts=1759060800",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Timestamp in the future",
+			query:          "from=EUR&to=MXN&ts=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported currency",
+			query:          "from=EUR&to=GBP&ts=2025-09-28T12:00:00Z",
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			logger := logrus.New()
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logger,
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/at?"+tt.query, nil)
+
+			rr := httptest.NewRecorder()
+			handler.GetQuoteAt(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.QuoteAtResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedAge, response.AgeSeconds)
+				assert.True(t, response.EffectiveAt.Equal(recordedAt))
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+// Источник состояния провайдеров для тестов
+type stubHealthReporter struct {
+	statuses []models.ProviderStatus
+}
+
+func (s *stubHealthReporter) ProviderStatuses() []models.ProviderStatus {
+	return s.statuses
+}
+
+func TestConvert(t *testing.T) {
+	snapshotID := int64(1187)
+	updatedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name              string
+		query             string
+		rate              string
+		quoteAge          time.Duration
+		expectQuote       bool
+		expectRefresh     bool
+		quoteErr          error
+		expectedStatus    int
+		expectedConverted string
+		expectedRounding  string
+	}{
+		{
+			name:              "Default half-even rounding",
+			query:             "from=EUR&to=MXN&amount=1234.56",
+			rate:              "21.6271",
+			expectQuote:       true,
+			expectedStatus:    http.StatusOK,
+			expectedConverted: "26699.95",
+			expectedRounding:  "half-even",
+		},
+		{
+			name:              "Half-up rounding",
+			query:             "from=MXN&to=USD&amount=1234.56&rounding=half-up",
+			rate:              "0.1",
+			expectQuote:       true,
+			expectedStatus:    http.StatusOK,
+			expectedConverted: "123.46",
+			expectedRounding:  "half-up",
+		},
+		{
+			name:              "Down rounding",
+			query:             "from=mxn&to=usd&amount=1234.56&rounding=down",
+			rate:              "0.1",
+			expectQuote:       true,
+			expectedStatus:    http.StatusOK,
+			expectedConverted: "123.45",
+			expectedRounding:  "down",
+		},
+		{
+			name:           "Missing amount",
+			query:          "from=EUR&to=MXN",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Too many decimal places",
+			query:          "from=EUR&to=MXN&amount=10.001",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid rounding",
+			query:          "from=EUR&to=MXN&amount=10&rounding=ceil",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported currency",
+			query:          "from=EUR&to=GBP&amount=10",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Quote not found",
+			query:          "from=EUR&to=MXN&amount=10",
+			rate:           "21.6271",
+			expectQuote:    true,
+			quoteErr:       database.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Stale quote rejected",
+			query:          "from=EUR&to=MXN&amount=10&max_age=1m&on_stale=reject",
+			rate:           "21.6271",
+			quoteAge:       time.Hour,
+			expectQuote:    true,
+			expectRefresh:  true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			if tt.expectQuote {
+				quote := &models.Quote{
+					ID:         "456",
+					From:       "EUR",
+					To:         "MXN",
+					Rate:       dec(tt.rate),
+					SnapshotID: &snapshotID,
+					UpdatedAt:  updatedAt.Add(-tt.quoteAge),
+				}
+				if tt.quoteErr != nil {
+					quote = nil
+				}
+				mockDB.On("GetQuote", mock.Anything, mock.Anything).Return(quote, tt.quoteErr)
+			}
+			if tt.expectRefresh {
+				mockDB.On("CreateOrGetPendingQuoteRequest", "EUR", "MXN").
+					Return(&models.QuoteRequest{ID: "refresh-1", Status: "pending"}, nil)
+			}
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+			}
+
+			req := httptest.NewRequest("GET", "/convert?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.Convert(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response models.ConversionResponse
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "1234.56", response.Amount)
+				assert.Equal(t, tt.expectedConverted, response.ConvertedAmount)
+				assert.Equal(t, tt.rate, response.Rate.String())
+				assert.Equal(t, tt.expectedRounding, response.Rounding)
+				assert.Equal(t, 2, response.MinorUnits)
+				assert.Equal(t, "456", response.QuoteID)
+				assert.Equal(t, &snapshotID, response.SnapshotID)
+				assert.True(t, updatedAt.Equal(response.QuoteUpdatedAt))
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateCurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+		expectEnabled  bool
+	}{
+		{
+			name:        "Known ISO code with defaults",
+			requestBody: `{"code": "brl"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateCurrency", &models.Currency{Code: "BRL", NumericCode: "986", MinorUnits: 2, Name: "Brazilian Real", Enabled: true}).
+					Return(&models.Currency{Code: "BRL", NumericCode: "986", MinorUnits: 2, Name: "Brazilian Real", Enabled: true}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectEnabled:  true,
+		},
+		{
+			name:        "Custom currency disabled",
+			requestBody: `{"code": "XYZ", "numeric_code": "999", "minor_units": 3, "name": "Test Currency", "enabled": false}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateCurrency", &models.Currency{Code: "XYZ", NumericCode: "999", MinorUnits: 3, Name: "Test Currency"}).
+					Return(&models.Currency{Code: "XYZ", NumericCode: "999", MinorUnits: 3, Name: "Test Currency"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:        "Already in registry",
+			requestBody: `{"code": "EUR"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("CreateCurrency", mock.AnythingOfType("*models.Currency")).Return((*models.Currency)(nil), database.ErrCurrencyExists)
 			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Invalid code",
+			requestBody:    `{"code": "EURO"}`,
+			mockSetup:      func(mockDB *MockDB) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name: "Unsupported from currency",
-			from: "GBP",
-			to:   "USD",
+			name:           "Unknown code without metadata",
+			requestBody:    `{"code": "XYZ"}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Minor units out of range",
+			requestBody:    `{"code": "BRL", "minor_units": 5}`,
+			mockSetup:      func(mockDB *MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				adminToken: "admin-secret",
+			}
+
+			req := httptest.NewRequest("POST", "/admin/currencies", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			rr := httptest.NewRecorder()
+			handler.CreateCurrency(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response models.Currency
+				err := json.Unmarshal(rr.Body.Bytes(), &response)
+				assert.NoError(t, err)
+
+				// Новая валюта сразу доступна в API без перезапуска
+				_, registered := handler.currencies.Get(response.Code)
+				assert.True(t, registered)
+				assert.Equal(t, tt.expectEnabled, handler.currencies.IsEnabled(response.Code))
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateCurrency(t *testing.T) {
+	mxn := &models.Currency{Code: "MXN", NumericCode: "484", MinorUnits: 2, Name: "Mexican Peso", Enabled: true}
+	usd := &models.Currency{Code: "USD", NumericCode: "840", MinorUnits: 2, Name: "US Dollar", Enabled: true}
+
+	tests := []struct {
+		name           string
+		code           string
+		requestBody    string
+		mockSetup      func(*MockDB)
+		expectedStatus int
+		expectEnabled  bool
+	}{
+		{
+			name:        "Disable currency",
+			code:        "mxn",
+			requestBody: `{"enabled": false}`,
 			mockSetup: func(mockDB *MockDB) {
-				// No mock setup needed for validation error
+				mockDB.On("GetCurrency", "MXN").Return(mxn, nil)
+				mockDB.On("UpdateCurrency", "MXN", "Mexican Peso", 2, false).
+					Return(&models.Currency{Code: "MXN", NumericCode: "484", MinorUnits: 2, Name: "Mexican Peso"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Rename currency",
+			code:        "MXN",
+			requestBody: `{"name": "Peso mexicano"}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetCurrency", "MXN").Return(mxn, nil)
+				mockDB.On("UpdateCurrency", "MXN", "Peso mexicano", 2, true).
+					Return(&models.Currency{Code: "MXN", NumericCode: "484", MinorUnits: 2, Name: "Peso mexicano", Enabled: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectEnabled:  true,
+		},
+		{
+			name:        "Disable USD",
+			code:        "USD",
+			requestBody: `{"enabled": false}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetCurrency", "USD").Return(usd, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectEnabled:  true,
 		},
 		{
-			name: "Unsupported to currency",
-			from: "USD",
-			to:   "GBP",
+			name:        "Change numeric code",
+			code:        "MXN",
+			requestBody: `{"numeric_code": "485"}`,
 			mockSetup: func(mockDB *MockDB) {
-				// No mock setup needed for validation error
+				mockDB.On("GetCurrency", "MXN").Return(mxn, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectEnabled:  true,
+		},
+		{
+			name:        "Currency not found",
+			code:        "BRL",
+			requestBody: `{"enabled": true}`,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetCurrency", "BRL").Return((*models.Currency)(nil), database.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
 		},
 	}
 
@@ -343,20 +2672,520 @@ func TestGetLatestQuote(t *testing.T) {
 			mockDB := new(MockDB)
 			tt.mockSetup(mockDB)
 
-			logger := logrus.New()
 			handler := &Handler{
-				db:                  mockDB,
-				logger:              logger,
-				supportedCurrencies: []string{"USD", "EUR", "MXN"},
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				adminToken: "admin-secret",
 			}
 
-			req := httptest.NewRequest("GET", "/quotes/latest?from="+tt.from+"&to="+tt.to, nil)
-
+			req := httptest.NewRequest("PUT", "/admin/currencies/"+tt.code, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			req = mux.SetURLVars(req, map[string]string{"code": tt.code})
 			rr := httptest.NewRecorder()
-			handler.GetLatestQuote(rr, req)
+			handler.UpdateCurrency(rr, req)
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			// Изменение сразу применяется к проверке валют в запросах
+			code := strings.ToUpper(tt.code)
+			assert.Equal(t, tt.expectEnabled, handler.currencies.IsEnabled(code))
+			if tt.expectedStatus == http.StatusOK && !tt.expectEnabled {
+				_, _, err := handler.normalizeCurrencyPair("EUR", code)
+				assert.Error(t, err)
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCurrencyRegistry_RequiresAdminToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+	}{
+		{name: "Missing header", adminToken: "admin-secret"},
+		{name: "Wrong token", adminToken: "admin-secret", authorization: "Bearer other"},
+		{name: "Not a bearer token", adminToken: "admin-secret", authorization: "admin-secret"},
+		{name: "Admin token not configured", authorization: "Bearer "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Без прав запросы не доходят до базы данных и не меняют реестр
+			mockDB := new(MockDB)
+			handler := &Handler{
+				db:         mockDB,
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				adminToken: tt.adminToken,
+			}
+
+			req := httptest.NewRequest("POST", "/admin/currencies", bytes.NewBufferString(`{"code": "BRL"}`))
+			req.Header.Set("Authorization", tt.authorization)
+			rr := httptest.NewRecorder()
+			handler.CreateCurrency(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+
+			req = httptest.NewRequest("PUT", "/admin/currencies/MXN", bytes.NewBufferString(`{"enabled": false}`))
+			req = mux.SetURLVars(req, map[string]string{"code": "MXN"})
+			req.Header.Set("Authorization", tt.authorization)
+			rr = httptest.NewRecorder()
+			handler.UpdateCurrency(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.True(t, handler.currencies.IsEnabled("MXN"))
+			_, registered := handler.currencies.Get("BRL")
+			assert.False(t, registered)
+
 			mockDB.AssertExpectations(t)
 		})
 	}
 }
+
+func TestGetCurrencies(t *testing.T) {
+	registry := currency.NewStatic("USD", "MXN", "EUR")
+	registry.Put(&models.Currency{Code: "BRL", NumericCode: "986", MinorUnits: 2, Name: "Brazilian Real"})
+
+	handler := &Handler{
+		db:         new(MockDB),
+		logger:     logrus.New(),
+		currencies: registry,
+	}
+
+	req := httptest.NewRequest("GET", "/currencies", nil)
+	rr := httptest.NewRecorder()
+	handler.GetCurrencies(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.CurrenciesResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// Отключённая BRL не возвращается, валюты отсортированы по коду
+	codes := make([]string, 0, len(response.Currencies))
+	for _, c := range response.Currencies {
+		codes = append(codes, c.Code)
+	}
+	assert.Equal(t, []string{"EUR", "MXN", "USD"}, codes)
+	assert.Equal(t, "484", response.Currencies[1].NumericCode)
+	assert.Equal(t, "Mexican Peso", response.Currencies[1].Name)
+	assert.Equal(t, 2, response.Currencies[1].MinorUnits)
+}
+
+func TestGetPairs(t *testing.T) {
+	now := time.Now()
+	quotes := []*models.Quote{
+		{ID: "1", From: "EUR", To: "MXN", Rate: dec("21.6271"), UpdatedAt: now.Add(-time.Minute)},
+		{ID: "2", From: "EUR", To: "USD", Rate: dec("1.17"), UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "3", From: "USD", To: "MXN", Rate: dec("18.45"), UpdatedAt: now.Add(-10 * time.Minute)},
+		{ID: "4", From: "USD", To: "BRL", Rate: dec("5.4"), UpdatedAt: now},
+	}
+
+	mockDB := new(MockDB)
+	mockDB.On("ListQuotes").Return(quotes, nil)
+
+	handler := &Handler{
+		db:         mockDB,
+		logger:     logrus.New(),
+		currencies: currency.NewStatic("USD", "EUR", "MXN"),
+		quotes: config.QuotesConfig{
+			MaxAge:     time.Hour,
+			PairMaxAge: map[string]time.Duration{"USD/MXN": 5 * time.Minute},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/pairs", nil)
+	rr := httptest.NewRecorder()
+	handler.GetPairs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.PairsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// USD/BRL не возвращается: BRL нет в реестре
+	statuses := make(map[string]string)
+	for _, pair := range response.Pairs {
+		statuses[pair.Pair] = pair.Status
+	}
+	assert.Equal(t, map[string]string{
+		"EUR/MXN": models.PairStatusFresh,
+		"EUR/USD": models.PairStatusStale,
+		"USD/MXN": models.PairStatusStale,
+	}, statuses)
+	assert.Equal(t, int64(300), response.Pairs[2].MaxAgeSeconds)
+
+	// Устаревшие пары не ставятся в очередь на обновление
+	mockDB.AssertNotCalled(t, "CreateOrGetPendingQuoteRequest", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestGetProviders(t *testing.T) {
+	tests := []struct {
+		name           string
+		providerHealth external.HealthReporter
+		expectedNames  []string
+	}{
+		{
+			name: "Provider chain",
+			providerHealth: &stubHealthReporter{statuses: []models.ProviderStatus{
+				{Name: "fxratesapi", State: models.CircuitOpen, ConsecutiveFailures: 3},
+				{Name: "ecb", State: models.CircuitClosed, Active: true},
+			}},
+			expectedNames: []string{"fxratesapi", "ecb"},
+		},
+		{
+			name:          "No reporter configured",
+			expectedNames: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{
+				logger:         logrus.New(),
+				providerHealth: tt.providerHealth,
+			}
+
+			req := httptest.NewRequest("GET", "/admin/providers", nil)
+
+			rr := httptest.NewRecorder()
+			handler.GetProviders(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response models.ProvidersResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, provider := range response.Providers {
+				names = append(names, provider.Name)
+			}
+			assert.Equal(t, tt.expectedNames, names)
+		})
+	}
+}
+
+func TestStreamQuotes_Validation(t *testing.T) {
+	tests := []struct {
+		name          string
+		pairs         string
+		expectedError string
+	}{
+		{name: "Missing pairs", pairs: "", expectedError: "pairs parameter is required"},
+		{name: "Malformed pair", pairs: "EURMXN", expectedError: "Invalid pair 'EURMXN', expected FROM/TO"},
+		{name: "Unsupported currency", pairs: "EUR/GBP", expectedError: "Currency 'GBP' is not supported"},
+		{name: "Same currencies", pairs: "EUR/EUR", expectedError: "currencies must be different"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{
+				db:         new(MockDB),
+				logger:     logrus.New(),
+				currencies: currency.NewStatic("USD", "EUR", "MXN"),
+				hub:        pubsub.New(),
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/stream?pairs="+tt.pairs, nil)
+			rr := httptest.NewRecorder()
+			handler.StreamQuotes(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var errorResp models.ErrorResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &errorResp)
+			assert.NoError(t, err)
+			assert.Contains(t, errorResp.Message, tt.expectedError)
+		})
+	}
+}
+
+func TestStreamQuotes_PushesUpdates(t *testing.T) {
+	updatedAt := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+
+	mockDB := new(MockDB)
+	mockDB.On("GetQuote", "EUR", "MXN").Return(&models.Quote{From: "EUR", To: "MXN", Rate: dec("21.6"), UpdatedAt: updatedAt}, nil)
+	mockDB.On("GetQuote", "USD", "EUR").Return((*models.Quote)(nil), assert.AnError)
+
+	hub := pubsub.New()
+	handler := &Handler{
+		db:         mockDB,
+		logger:     logrus.New(),
+		currencies: currency.NewStatic("USD", "EUR", "MXN"),
+		hub:        hub,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler.StreamQuotes))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/quotes/stream?pairs=eur/mxn,USD/EUR")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() models.QuoteUpdateEvent {
+		var event models.QuoteUpdateEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if !assert.NoError(t, err) {
+				return event
+			}
+			if strings.HasPrefix(line, "data: ") {
+				assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+				return event
+			}
+		}
+	}
+
+	// Первым приходит снимок текущей котировки
+	snapshot := readEvent()
+	assert.Equal(t, "EUR", snapshot.From)
+	assert.Equal(t, "21.6", snapshot.Rate.String())
+
+	// Обновления неподписанных пар не доставляются
+	hub.Publish(pubsub.QuoteTopic("USD", "MXN"), models.QuoteUpdateEvent{From: "USD", To: "MXN", Rate: dec("18.5")})
+	hub.Publish(pubsub.QuoteTopic("USD", "EUR"), models.QuoteUpdateEvent{From: "USD", To: "EUR", Rate: dec("0.92")})
+
+	update := readEvent()
+	assert.Equal(t, "USD", update.From)
+	assert.Equal(t, "EUR", update.To)
+	assert.Equal(t, "0.92", update.Rate.String())
+
+	// После отключения клиента подписка снимается
+	resp.Body.Close()
+	assert.Eventually(t, func() bool { return hub.SubscriberCount() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestServeWebSocket(t *testing.T) {
+	updatedAt := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+
+	mockDB := new(MockDB)
+	mockDB.On("GetQuote", "EUR", "MXN").Return(&models.Quote{From: "EUR", To: "MXN", Rate: dec("21.6"), UpdatedAt: updatedAt}, nil)
+	mockDB.On("CreateOrGetPendingQuoteRequest", "USD", "MXN").Return(&models.QuoteRequest{
+		ID:     "123",
+		From:   "USD",
+		To:     "MXN",
+		Status: "pending",
+	}, nil)
+	mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+		ID:     "123",
+		From:   "USD",
+		To:     "MXN",
+		Status: "pending",
+	}, nil)
+
+	hub := pubsub.New()
+	handler := &Handler{
+		db:         mockDB,
+		logger:     logrus.New(),
+		currencies: currency.NewStatic("USD", "EUR", "MXN"),
+		hub:        hub,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler.ServeWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	send := func(message models.WSClientMessage) {
+		assert.NoError(t, conn.WriteJSON(message))
+	}
+	receive := func() models.WSServerMessage {
+		var message models.WSServerMessage
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		assert.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+
+	// Подписка подтверждается и сразу приходит текущая котировка
+	send(models.WSClientMessage{Type: "subscribe", ID: "1", Pairs: []string{"eur/mxn"}})
+	subscribed := receive()
+	assert.Equal(t, "subscribed", subscribed.Type)
+	assert.Equal(t, "1", subscribed.ID)
+	assert.Equal(t, []string{"EUR/MXN"}, subscribed.Pairs)
+
+	snapshot := receive()
+	assert.Equal(t, "quote", snapshot.Type)
+	if assert.NotNil(t, snapshot.Quote) {
+		assert.Equal(t, "21.6", snapshot.Quote.Rate.String())
+	}
+
+	// Обновление котировки подписанной пары доставляется
+	hub.Publish(pubsub.QuoteTopic("EUR", "MXN"), models.QuoteUpdateEvent{From: "EUR", To: "MXN", Rate: dec("21.7")})
+	update := receive()
+	assert.Equal(t, "quote", update.Type)
+	if assert.NotNil(t, update.Quote) {
+		assert.Equal(t, "21.7", update.Quote.Rate.String())
+	}
+
+	// Запуск обновления и получение смены статуса запроса
+	send(models.WSClientMessage{Type: "update", ID: "2", From: "usd", To: "mxn"})
+	accepted := receive()
+	assert.Equal(t, "request_accepted", accepted.Type)
+	assert.Equal(t, "2", accepted.ID)
+	if assert.NotNil(t, accepted.Request) {
+		assert.Equal(t, "123", accepted.Request.ID)
+		assert.Equal(t, "pending", accepted.Request.Status)
+	}
+
+	hub.Publish(pubsub.RequestTopic("123"), models.QuoteRequestStatusResponse{ID: "123", Status: "completed"})
+	status := receive()
+	assert.Equal(t, "request_status", status.Type)
+	if assert.NotNil(t, status.Request) {
+		assert.Equal(t, "completed", status.Request.Status)
+	}
+
+	// После отписки обновления пары не приходят
+	send(models.WSClientMessage{Type: "unsubscribe", ID: "3", Pairs: []string{"EUR/MXN"}})
+	assert.Equal(t, "unsubscribed", receive().Type)
+	hub.Publish(pubsub.QuoteTopic("EUR", "MXN"), models.QuoteUpdateEvent{From: "EUR", To: "MXN", Rate: dec("21.8")})
+
+	// Ошибки валидации не разрывают соединение
+	send(models.WSClientMessage{Type: "subscribe", ID: "4", Pairs: []string{"EUR/GBP"}})
+	validation := receive()
+	assert.Equal(t, "error", validation.Type)
+	assert.Equal(t, "4", validation.ID)
+	assert.Contains(t, validation.Message, "Currency 'GBP' is not supported")
+
+	send(models.WSClientMessage{Type: "unknown", ID: "5"})
+	assert.Equal(t, "error", receive().Type)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+	assert.Equal(t, "Invalid JSON", receive().Error)
+
+	send(models.WSClientMessage{Type: "ping", ID: "6"})
+	pong := receive()
+	assert.Equal(t, "pong", pong.Type)
+	assert.Equal(t, "6", pong.ID)
+
+	// После закрытия соединения подписка снимается
+	conn.Close()
+	assert.Eventually(t, func() bool { return hub.SubscriberCount() == 0 }, time.Second, 10*time.Millisecond)
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestServeWebSocket_UpdateCompletedBeforeSubscription(t *testing.T) {
+	historyID := int64(9)
+
+	// Воркер завершил запрос между его созданием и подпиской, событие шины уже потеряно
+	mockDB := new(MockDB)
+	mockDB.On("CreateOrGetPendingQuoteRequest", "USD", "MXN").Return(&models.QuoteRequest{
+		ID:     "123",
+		From:   "USD",
+		To:     "MXN",
+		Status: "pending",
+	}, nil)
+	mockDB.On("GetQuoteRequest", "123").Return(&models.QuoteRequest{
+		ID:             "123",
+		From:           "USD",
+		To:             "MXN",
+		Status:         "completed",
+		QuoteHistoryID: &historyID,
+	}, nil)
+	mockDB.On("GetQuoteHistoryEntry", historyID).Return(&models.QuoteHistoryEntry{ID: historyID, From: "USD", To: "MXN", Rate: dec("18.45")}, nil)
+
+	hub := pubsub.New()
+	handler := &Handler{
+		db:         mockDB,
+		logger:     logrus.New(),
+		currencies: currency.NewStatic("USD", "EUR", "MXN"),
+		hub:        hub,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler.ServeWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	receive := func() models.WSServerMessage {
+		var message models.WSServerMessage
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		assert.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+
+	assert.NoError(t, conn.WriteJSON(models.WSClientMessage{Type: "update", ID: "1", From: "USD", To: "MXN"}))
+	assert.Equal(t, "request_accepted", receive().Type)
+
+	status := receive()
+	assert.Equal(t, "request_status", status.Type)
+	if assert.NotNil(t, status.Request) {
+		assert.Equal(t, "completed", status.Request.Status)
+		if assert.NotNil(t, status.Request.Quote) {
+			assert.Equal(t, "18.45", status.Request.Quote.Rate.String())
+		}
+	}
+
+	mockDB.AssertExpectations(t)
+}
+
+func TestServeWebSocket_Origin(t *testing.T) {
+	tests := []struct {
+		name           string
+		origin         string
+		expectedStatus int
+	}{
+		{name: "Foreign origin", origin: "https://evil.example", expectedStatus: http.StatusForbidden},
+		{name: "Allowed origin", origin: "https://app.example.com", expectedStatus: http.StatusSwitchingProtocols},
+		{name: "Same origin", origin: "same", expectedStatus: http.StatusSwitchingProtocols},
+		{name: "No origin", expectedStatus: http.StatusSwitchingProtocols},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{
+				db:               new(MockDB),
+				logger:           logrus.New(),
+				currencies:       currency.NewStatic("USD", "EUR", "MXN"),
+				hub:              pubsub.New(),
+				wsAllowedOrigins: []string{"https://app.example.com"},
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(handler.ServeWebSocket))
+			defer server.Close()
+
+			header := http.Header{}
+			switch tt.origin {
+			case "":
+			case "same":
+				header.Set("Origin", server.URL)
+			default:
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+			if conn != nil {
+				conn.Close()
+			}
+			if tt.expectedStatus != http.StatusSwitchingProtocols {
+				assert.Error(t, err)
+			}
+			if assert.NotNil(t, resp) {
+				assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Матрица кросс-курсов
+// @Description Возвращает таблицу N×N курсов для указанных валют: rates[i][j] — курс currencies[i]/currencies[j].
+// @Description Все курсы рассчитываются из последнего сохранённого снимка курсов относительно USD, поэтому матрица согласована.
+// @Description Для валют, которых нет в снимке, ячейки строки и столбца равны null, а сами валюты перечислены в missing.
+// @Description Снимок старше max_age (по умолчанию QUOTE_MAX_AGE) считается устаревшим: воркер получает новый, а ответ зависит от on_stale.
+// @Tags quotes
+// @Produce json
+// @Param currencies query string false "Валюты через запятую, например USD,EUR,MXN. По умолчанию все включённые валюты реестра в алфавитном порядке"
+// @Param max_age query string false "Максимальный возраст снимка курсов (10m или число секунд)"
+// @Param on_stale query string false "Поведение для устаревшего снимка: refresh (вернуть с stale=true) или reject (503)" Enums(refresh, reject)
+// @Success 200 {object} models.QuoteMatrixResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse "Снимок курсов ещё не сохранён или устарел при on_stale=reject"
+// @Router /quotes/matrix [get]
+func (h *Handler) GetQuoteMatrix(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	currencies, err := h.parseMatrixCurrencies(query.Get("currencies"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	// Матрица строится из одного снимка для всех пар, поэтому действует общий QUOTE_MAX_AGE
+	maxAge, stalePolicy, err := h.parseStaleness(query, "", "")
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	snapshot, err := h.db.GetLatestRateSnapshot()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to get latest rates snapshot for matrix")
+		// Воркер сохраняет снимок курсов на ближайшем проходе, ускоряем его
+		if h.updateTrigger != nil {
+			h.updateTrigger.Trigger()
+		}
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Rates unavailable", "Rates snapshot is not available yet")
+		return
+	}
+
+	response := models.QuoteMatrixResponse{
+		Currencies: currencies,
+		Rates:      make([][]*decimal.Decimal, len(currencies)),
+		Provider:   snapshot.Rates.Provider,
+		Sources:    snapshot.Rates.SourcesFor(currencies...),
+		AsOf:       snapshot.FetchedAt,
+		SnapshotID: snapshot.ID,
+	}
+
+	if maxAge > 0 {
+		response.Freshness = quoteFreshness(time.Since(snapshot.FetchedAt), maxAge)
+	}
+	if response.Freshness != nil && response.Freshness.Stale {
+		h.logger.WithFields(logrus.Fields{
+			"snapshot_id": snapshot.ID,
+			"fetched_at":  snapshot.FetchedAt,
+			"max_age":     maxAge,
+			"policy":      stalePolicy,
+		}).Warn("Stale rates snapshot requested for matrix")
+
+		// Воркер получает новый снимок, если текущий старше WORKER_SNAPSHOT_INTERVAL
+		if h.updateTrigger != nil {
+			h.updateTrigger.Trigger()
+		}
+		if stalePolicy == config.StalePolicyReject {
+			h.writeErrorResponse(w, http.StatusServiceUnavailable, "Stale rates",
+				fmt.Sprintf("Rates snapshot is older than %s, refresh has been requested", maxAge))
+			return
+		}
+	}
+
+	// Валюты без курса в снимке не ломают матрицу: их ячейки остаются null
+	available := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		if rate, exists := snapshot.Rates.Rates[currency]; exists && rate.IsPositive() {
+			available[currency] = true
+		} else {
+			response.Missing = append(response.Missing, currency)
+		}
+	}
+	if len(response.Missing) > 0 {
+		h.logger.WithFields(logrus.Fields{
+			"snapshot_id": snapshot.ID,
+			"missing":     response.Missing,
+		}).Warn("Currencies missing in rates snapshot")
+	}
+
+	for i, from := range currencies {
+		response.Rates[i] = make([]*decimal.Decimal, len(currencies))
+		for j, to := range currencies {
+			if !available[from] || !available[to] {
+				continue
+			}
+			if from == to {
+				one := decimal.NewFromInt(1)
+				response.Rates[i][j] = &one
+				continue
+			}
+
+			rate, err := utils.CalculateExchangeRate(from, to, snapshot.Rates.Rates, h.quotes.RatePrecision)
+			if err != nil {
+				h.logger.WithError(err).WithField("pair", from+"/"+to).Warn("Failed to calculate matrix rate")
+				continue
+			}
+			response.Rates[i][j] = &rate
+		}
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// Разбираем список валют матрицы. Без параметра используются все включённые валюты реестра
+func (h *Handler) parseMatrixCurrencies(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return h.currencies.Enabled(), nil
+	}
+
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, raw := range strings.Split(value, ",") {
+		currency := strings.ToUpper(strings.TrimSpace(raw))
+		if err := h.checkCurrency(currency); err != nil {
+			return nil, err
+		}
+		if seen[currency] {
+			continue
+		}
+		seen[currency] = true
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, nil
+}
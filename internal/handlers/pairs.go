@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+)
+
+// @Summary Валютные пары с котировками
+// @Description Возвращает пары включённых валют, для которых сохранена котировка, с временем последнего обновления и состоянием:
+// @Description fresh — котировка не старше допустимого возраста пары (QUOTE_MAX_AGE_PAIRS или QUOTE_MAX_AGE), stale — старше.
+// @Description В отличие от /quotes/latest, устаревшие пары не ставятся в очередь на обновление.
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} models.PairsResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /pairs [get]
+func (h *Handler) GetPairs(w http.ResponseWriter, r *http.Request) {
+	quotes, err := h.db.ListQuotes()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list quotes")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get pairs")
+		return
+	}
+
+	now := time.Now()
+	response := models.PairsResponse{Pairs: make([]models.PairInfo, 0, len(quotes))}
+	for _, quote := range quotes {
+		// Пары с отключёнными валютами API не принимает, поэтому не показываем их
+		if !h.currencies.IsEnabled(quote.From) || !h.currencies.IsEnabled(quote.To) {
+			continue
+		}
+
+		freshness := quoteFreshness(now.Sub(quote.UpdatedAt), h.quotes.MaxAgeFor(quote.From, quote.To))
+		status := models.PairStatusFresh
+		if freshness.Stale {
+			status = models.PairStatusStale
+		}
+
+		response.Pairs = append(response.Pairs, models.PairInfo{
+			Pair:          models.CurrencyPair{From: quote.From, To: quote.To}.String(),
+			From:          quote.From,
+			To:            quote.To,
+			UpdatedAt:     quote.UpdatedAt,
+			Status:        status,
+			AgeSeconds:    freshness.AgeSeconds,
+			MaxAgeSeconds: freshness.MaxAgeSeconds,
+		})
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
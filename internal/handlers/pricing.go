@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Разбираем уровень клиента из параметра tier и возвращаем его наценку в базисных пунктах
+func (h *Handler) parseTier(query url.Values) (string, decimal.Decimal, error) {
+	tier := strings.ToLower(strings.TrimSpace(query.Get("tier")))
+	markupBps, exists := h.pricing.MarkupFor(tier)
+	if !exists {
+		return "", decimal.Zero, fmt.Errorf("Unknown tier '%s'", tier)
+	}
+	return tier, markupBps, nil
+}
+
+// Добавляем к ответу bid, ask и mid по спреду пары и наценке уровня клиента
+func (h *Handler) applyPricing(response *models.QuoteResponse, tier string, markupBps decimal.Decimal) error {
+	bid, ask, err := utils.ApplySpread(response.Rate, h.pricing.SpreadFor(response.From, response.To), markupBps, h.quotes.RatePrecision)
+	if err != nil {
+		return fmt.Errorf("failed to price %s/%s: %w", response.From, response.To, err)
+	}
+
+	mid := response.Rate
+	response.Bid = &bid
+	response.Ask = &ask
+	response.Mid = &mid
+	response.Tier = tier
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// @Summary Последний снимок курсов
+// @Description Возвращает последний сохранённый снимок курсов относительно USD: провайдер, дата курсов по данным провайдера и время получения.
+// @Description Из снимка можно рассчитать курс любой пары поддерживаемых валют, в том числе ещё не запрошенной.
+// @Tags rates
+// @Produce json
+// @Success 200 {object} models.RateSnapshotResponse
+// @Failure 404 {object} models.ErrorResponse "Снимков курсов ещё нет"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /rates/snapshots/latest [get]
+func (h *Handler) GetLatestRateSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.db.GetLatestRateSnapshot()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get latest rates snapshot")
+		h.writeLookupError(w, err, "No rates snapshot has been stored yet")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, snapshot.Response())
+}
+
+// @Summary Снимок курсов по ID
+// @Description Возвращает снимок курсов, на который ссылается snapshot_id котировки или записи истории
+// @Tags rates
+// @Produce json
+// @Param id path int true "ID снимка курсов"
+// @Success 200 {object} models.RateSnapshotResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /rates/snapshots/{id} [get]
+func (h *Handler) GetRateSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Rates snapshot ID must be a positive integer")
+		return
+	}
+
+	snapshot, err := h.db.GetRateSnapshot(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("snapshot_id", id).Error("Failed to get rates snapshot")
+		h.writeLookupError(w, err, "Rates snapshot not found")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, snapshot.Response())
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Настройки потока Server-Sent Events
+const (
+	streamKeepAliveInterval = 15 * time.Second // Период комментариев keep-alive для прокси
+	streamBufferSize        = 64               // Очередь событий одного клиента
+)
+
+// @Summary Поток обновлений котировок (Server-Sent Events)
+// @Description Держит SSE соединение открытым и отправляет событие quote при каждом обновлении курса подписанных пар.
+// @Description Сразу после подключения отправляются текущие котировки пар, если они есть.
+// @Tags quotes
+// @Produce text/event-stream
+// @Param pairs query string true "Список пар через запятую (например, EUR/MXN,USD/EUR)"
+// @Success 200 {object} models.QuoteUpdateEvent "Поток событий quote"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /quotes/stream [get]
+func (h *Handler) StreamQuotes(w http.ResponseWriter, r *http.Request) {
+	pairs, err := h.parseCurrencyPairs(r.URL.Query().Get("pairs"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	if h.hub == nil {
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Unavailable", "Quote stream is not available")
+		return
+	}
+
+	// Снимаем WriteTimeout сервера: соединение живёт, пока клиент не отключится
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.WithError(err).Warn("Failed to clear write deadline for quote stream")
+	}
+
+	topics := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		topics = append(topics, pubsub.QuoteTopic(pair.From, pair.To))
+	}
+
+	subscription := h.hub.Subscribe(streamBufferSize, topics...)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Отправляем текущие котировки, чтобы клиенту не ждать следующего обновления
+	for _, pair := range pairs {
+		quote, err := h.db.GetQuote(pair.From, pair.To)
+		if err != nil {
+			continue
+		}
+		h.writeQuoteEvent(w, models.QuoteUpdateEvent{
+			From:      quote.From,
+			To:        quote.To,
+			Rate:      quote.Rate,
+			Sources:   quote.Sources,
+			UpdatedAt: quote.UpdatedAt,
+		})
+	}
+	if err := controller.Flush(); err != nil {
+		h.logger.WithError(err).Error("Quote stream is not supported by response writer")
+		return
+	}
+
+	h.logger.WithField("pairs", topics).Info("Quote stream opened")
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			h.logger.WithFields(logrus.Fields{
+				"pairs":   topics,
+				"dropped": subscription.Dropped(),
+			}).Info("Quote stream closed")
+			return
+		case message, ok := <-subscription.C():
+			if !ok {
+				return
+			}
+			event, ok := message.Data.(models.QuoteUpdateEvent)
+			if !ok {
+				continue
+			}
+			if err := h.writeQuoteEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Записываем событие quote в формате Server-Sent Events
+func (h *Handler) writeQuoteEvent(w http.ResponseWriter, event models.QuoteUpdateEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode quote event")
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: quote\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Список наблюдения
+// @Description Возвращает валютные пары, котировки которых обновляются автоматически по расписанию
+// @Tags watchlist
+// @Produce json
+// @Success 200 {object} models.WatchlistResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /watchlist [get]
+func (h *Handler) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.db.GetWatchlist()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get watchlist")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to get watchlist")
+		return
+	}
+
+	response := models.WatchlistResponse{Entries: entries}
+	if response.Entries == nil {
+		response.Entries = []*models.WatchlistEntry{}
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// @Summary Добавить пару в список наблюдения
+// @Description Добавляет валютную пару с расписанием автоматического обновления котировки.
+// @Description Указывается либо interval (например, "5m"), либо schedule: "@every 5m" или cron выражение из пяти полей в UTC ("*/15 9-18 * * 1-5").
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Param request body models.WatchlistEntryRequest true "Пара и расписание обновления"
+// @Success 201 {object} models.WatchlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Пара уже есть в списке наблюдения"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /watchlist [post]
+func (h *Handler) CreateWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	var req models.WatchlistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	from, to, err := h.normalizeCurrencyPair(req.From, req.To)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+	if from == to {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "From and To currencies must be different")
+		return
+	}
+
+	expr, schedule, err := resolveWatchlistSchedule(req)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+		return
+	}
+
+	nextRunAt := schedule.Next(time.Now())
+	if nextRunAt.IsZero() {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Schedule '"+expr+"' never fires")
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	entry, err := h.db.CreateWatchlistEntry(from, to, expr, enabled, nextRunAt)
+	if err != nil {
+		if errors.Is(err, database.ErrWatchlistEntryExists) {
+			h.writeErrorResponse(w, http.StatusConflict, "Already exists",
+				fmt.Sprintf("Currency pair %s/%s is already in the watchlist", from, to))
+			return
+		}
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+		}).Error("Failed to create watchlist entry")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to create watchlist entry")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"watchlist_id": entry.ID,
+		"from":         from,
+		"to":           to,
+		"schedule":     expr,
+	}).Info("Currency pair added to watchlist")
+
+	h.writeJSONResponse(w, http.StatusCreated, entry)
+}
+
+// @Summary Получить запись списка наблюдения
+// @Description Возвращает валютную пару из списка наблюдения с расписанием и временем следующего обновления
+// @Tags watchlist
+// @Produce json
+// @Param id path int true "ID записи списка наблюдения"
+// @Success 200 {object} models.WatchlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /watchlist/{id} [get]
+func (h *Handler) GetWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.lookupWatchlistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, entry)
+}
+
+// @Summary Изменить запись списка наблюдения
+// @Description Изменяет расписание (interval или schedule) и признак активности. Валютную пару изменить нельзя.
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Param id path int true "ID записи списка наблюдения"
+// @Param request body models.WatchlistEntryRequest true "Новое расписание и признак активности"
+// @Success 200 {object} models.WatchlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /watchlist/{id} [put]
+func (h *Handler) UpdateWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.lookupWatchlistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.WatchlistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	// Пара задаёт запись, поэтому её изменение требует удаления и повторного добавления
+	if (req.From != "" && !strings.EqualFold(req.From, entry.From)) || (req.To != "" && !strings.EqualFold(req.To, entry.To)) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Currency pair of a watchlist entry cannot be changed")
+		return
+	}
+
+	expr := entry.Schedule
+	nextRunAt := entry.NextRunAt
+	enabled := entry.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	// Пересчитываем время следующего обновления при смене расписания или повторном включении
+	if req.Interval != "" || req.Schedule != "" || (enabled && !entry.Enabled) {
+		var schedule utils.Schedule
+		var err error
+		if req.Interval != "" || req.Schedule != "" {
+			expr, schedule, err = resolveWatchlistSchedule(req)
+		} else {
+			schedule, err = utils.ParseSchedule(expr)
+		}
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", err.Error())
+			return
+		}
+
+		nextRunAt = schedule.Next(time.Now())
+		if nextRunAt.IsZero() {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Schedule '"+expr+"' never fires")
+			return
+		}
+	}
+
+	updated, err := h.db.UpdateWatchlistEntry(entry.ID, expr, enabled, nextRunAt)
+	if err != nil {
+		h.logger.WithError(err).WithField("watchlist_id", entry.ID).Error("Failed to update watchlist entry")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to update watchlist entry")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, updated)
+}
+
+// @Summary Удалить пару из списка наблюдения
+// @Description Прекращает автоматическое обновление котировки валютной пары
+// @Tags watchlist
+// @Param id path int true "ID записи списка наблюдения"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /watchlist/{id} [delete]
+func (h *Handler) DeleteWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.lookupWatchlistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteWatchlistEntry(entry.ID); err != nil {
+		h.logger.WithError(err).WithField("watchlist_id", entry.ID).Error("Failed to delete watchlist entry")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Internal error", "Failed to delete watchlist entry")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"watchlist_id": entry.ID,
+		"from":         entry.From,
+		"to":           entry.To,
+	}).Info("Currency pair removed from watchlist")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Получаем запись списка наблюдения по ID из пути. При ошибке ответ уже записан
+func (h *Handler) lookupWatchlistEntry(w http.ResponseWriter, r *http.Request) (*models.WatchlistEntry, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Validation error", "Watchlist entry ID must be a positive integer")
+		return nil, false
+	}
+
+	entry, err := h.db.GetWatchlistEntry(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("watchlist_id", id).Error("Failed to get watchlist entry")
+		h.writeLookupError(w, err, "Watchlist entry not found")
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Определяем расписание записи списка наблюдения: interval превращается в "@every <interval>"
+func resolveWatchlistSchedule(req models.WatchlistEntryRequest) (string, utils.Schedule, error) {
+	interval := strings.TrimSpace(req.Interval)
+	expr := strings.TrimSpace(req.Schedule)
+
+	switch {
+	case interval != "" && expr != "":
+		return "", nil, fmt.Errorf("only one of interval and schedule can be set")
+	case interval != "":
+		duration, err := time.ParseDuration(interval)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid interval '%s', expected duration like 30s, 5m or 1h", interval)
+		}
+		expr = "@every " + duration.String()
+	case expr == "":
+		return "", nil, fmt.Errorf("interval or schedule is required")
+	}
+
+	schedule, err := utils.ParseSchedule(expr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return expr, schedule, nil
+}
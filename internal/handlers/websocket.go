@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Настройки WebSocket соединения
+const (
+	wsWriteWait      = 10 * time.Second    // Таймаут записи одного сообщения
+	wsPongWait       = 60 * time.Second    // Время ожидания pong от клиента
+	wsPingPeriod     = wsPongWait * 9 / 10 // Период отправки ping
+	wsMaxMessageSize = 4096                // Максимальный размер сообщения клиента
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// Проверяем источник WebSocket соединения. Браузеры не применяют CORS к WebSocket,
+// поэтому без проверки любая сторонняя страница открыла бы соединение от имени
+// пользователя. Разрешены тот же источник, что и у сервиса, источники из
+// WS_ALLOWED_ORIGINS и клиенты вне браузера, не передающие Origin
+func (h *Handler) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range h.wsAllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(strings.TrimSpace(allowed), "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary WebSocket API котировок
+// @Description Двусторонний канал для подписки на котировки и запуска обновлений.
+// @Description Сообщения клиента: {"type":"subscribe","pairs":["EUR/MXN"]}, {"type":"unsubscribe","pairs":["EUR/MXN"]}, {"type":"update","from":"EUR","to":"MXN"}, {"type":"ping"}.
+// @Description Сообщения сервера: subscribed, unsubscribed, quote, request_accepted, request_status, pong, error (см. models.WSServerMessage).
+// @Tags quotes
+// @Success 101 {object} models.WSServerMessage "Соединение переключено на WebSocket"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {string} string "Источник (Origin) не разрешён"
+// @Failure 503 {object} models.ErrorResponse
+// @Router /ws [get]
+func (h *Handler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	if h.hub == nil {
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Unavailable", "WebSocket API is not available")
+		return
+	}
+
+	upgrader := wsUpgrader
+	upgrader.CheckOrigin = h.checkWebSocketOrigin
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader уже отправил клиенту ответ с ошибкой
+		h.logger.WithError(err).Warn("Failed to upgrade WebSocket connection")
+		return
+	}
+
+	session := &wsSession{
+		handler:      h,
+		conn:         conn,
+		subscription: h.hub.Subscribe(streamBufferSize),
+		out:          make(chan models.WSServerMessage, streamBufferSize),
+		done:         make(chan struct{}),
+	}
+
+	h.logger.WithField("remote_addr", r.RemoteAddr).Info("WebSocket connection opened")
+
+	go session.writeLoop()
+	session.readLoop()
+
+	h.logger.WithFields(logrus.Fields{
+		"remote_addr": r.RemoteAddr,
+		"dropped":     session.subscription.Dropped(),
+	}).Info("WebSocket connection closed")
+}
+
+// Состояние одного WebSocket соединения. Писать в conn может только writeLoop
+type wsSession struct {
+	handler      *Handler
+	conn         *websocket.Conn
+	subscription *pubsub.Subscription
+	out          chan models.WSServerMessage
+	done         chan struct{} // Закрывается, когда writeLoop завершился
+}
+
+// Читаем и обрабатываем сообщения клиента до разрыва соединения
+func (s *wsSession) readLoop() {
+	defer func() {
+		s.subscription.Close()
+		s.conn.Close()
+		<-s.done
+	}()
+
+	s.conn.SetReadLimit(wsMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			// Клиент закрыл соединение или оно разорвано
+			return
+		}
+
+		var message models.WSClientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			// Некорректное сообщение не разрывает соединение
+			s.sendError("", "Invalid JSON", err.Error())
+			continue
+		}
+
+		s.handle(message)
+	}
+}
+
+// Обрабатываем одно сообщение клиента
+func (s *wsSession) handle(message models.WSClientMessage) {
+	switch message.Type {
+	case "subscribe", "unsubscribe":
+		pairs, err := s.handler.parseCurrencyPairs(strings.Join(message.Pairs, ","))
+		if err != nil {
+			s.sendError(message.ID, "Validation error", err.Error())
+			return
+		}
+
+		topics := make([]string, 0, len(pairs))
+		names := make([]string, 0, len(pairs))
+		for _, pair := range pairs {
+			topics = append(topics, pubsub.QuoteTopic(pair.From, pair.To))
+			names = append(names, pair.String())
+		}
+
+		if message.Type == "unsubscribe" {
+			s.subscription.Remove(topics...)
+			s.send(models.WSServerMessage{Type: "unsubscribed", ID: message.ID, Pairs: names})
+			return
+		}
+
+		s.subscription.Add(topics...)
+		s.send(models.WSServerMessage{Type: "subscribed", ID: message.ID, Pairs: names})
+
+		// Отправляем текущие котировки, чтобы клиенту не ждать следующего обновления
+		for _, pair := range pairs {
+			quote, err := s.handler.db.GetQuote(pair.From, pair.To)
+			if err != nil {
+				continue
+			}
+			s.send(models.WSServerMessage{Type: "quote", Quote: &models.QuoteUpdateEvent{
+				From:      quote.From,
+				To:        quote.To,
+				Rate:      quote.Rate,
+				Sources:   quote.Sources,
+				UpdatedAt: quote.UpdatedAt,
+			}})
+		}
+
+	case "update":
+		from, to, err := s.handler.normalizeCurrencyPair(message.From, message.To)
+		if err != nil {
+			s.sendError(message.ID, "Validation error", err.Error())
+			return
+		}
+		if from == to {
+			s.sendError(message.ID, "Validation error", "From and To currencies must be different")
+			return
+		}
+
+		quoteRequest, err := s.handler.db.CreateOrGetPendingQuoteRequest(from, to)
+		if err != nil {
+			s.handler.logger.WithError(err).WithFields(logrus.Fields{
+				"from": from,
+				"to":   to,
+			}).Error("Failed to create or get quote request")
+			s.sendError(message.ID, "Internal error", "Failed to create quote request")
+			return
+		}
+
+		// Подписываемся на смену статуса созданного запроса
+		topic := pubsub.RequestTopic(quoteRequest.ID)
+		s.subscription.Add(topic)
+
+		status := quoteRequest.StatusResponse()
+		s.send(models.WSServerMessage{Type: "request_accepted", ID: message.ID, Request: &status})
+
+		// Запрос мог завершиться до подписки, и событие воркера тогда уже не придёт.
+		// Перечитываем его и сами отправляем финальный статус
+		current, err := s.handler.db.GetQuoteRequest(quoteRequest.ID)
+		if err != nil {
+			s.handler.logger.WithError(err).WithField("request_id", quoteRequest.ID).Error("Failed to get quote request")
+			return
+		}
+		if current.Status == "completed" || current.Status == "dead" {
+			s.subscription.Remove(topic)
+			final := current.StatusResponse()
+			final.Quote = s.handler.linkedQuote(current)
+			s.send(models.WSServerMessage{Type: "request_status", Request: &final})
+		}
+
+	case "ping":
+		s.send(models.WSServerMessage{Type: "pong", ID: message.ID})
+
+	default:
+		s.sendError(message.ID, "Validation error", "Unknown message type '"+message.Type+"'")
+	}
+}
+
+// Отправляем сообщение клиенту через writeLoop
+func (s *wsSession) send(message models.WSServerMessage) {
+	select {
+	case s.out <- message:
+	case <-s.done:
+	}
+}
+
+// Отправляем клиенту сообщение об ошибке
+func (s *wsSession) sendError(id, errorText, message string) {
+	s.send(models.WSServerMessage{Type: "error", ID: id, Error: errorText, Message: message})
+}
+
+// Пишем клиенту ответы, события шины и ping до закрытия соединения
+func (s *wsSession) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+		close(s.done)
+	}()
+
+	for {
+		var message models.WSServerMessage
+
+		select {
+		case message = <-s.out:
+		case event, ok := <-s.subscription.C():
+			if !ok {
+				return
+			}
+			switch data := event.Data.(type) {
+			case models.QuoteUpdateEvent:
+				message = models.WSServerMessage{Type: "quote", Quote: &data}
+			case models.QuoteRequestStatusResponse:
+				message = models.WSServerMessage{Type: "request_status", Request: &data}
+				// После финального статуса события по запросу больше не придут
+				if data.Status == "completed" || data.Status == "dead" {
+					s.subscription.Remove(event.Topic)
+				}
+			default:
+				continue
+			}
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := s.conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
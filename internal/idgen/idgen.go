@@ -0,0 +1,124 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые генераторы ID
+const (
+	KindUUIDv7 = "uuidv7"
+	KindULID   = "ulid"
+)
+
+// Форматы ID. Числовые ID выдавались до появления генераторов и остаются действительными
+var (
+	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`)
+	legacyPattern = regexp.MustCompile(`^[0-9]{1,19}$`)
+)
+
+// Алфавит Crockford Base32 для ULID
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Generator выдаёт уникальные ID запросов и котировок
+type Generator interface {
+	NewID() string
+}
+
+// Создаём генератор ID по названию из конфигурации
+func New(kind string) (Generator, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case KindUUIDv7, "":
+		return UUIDv7{}, nil
+	case KindULID:
+		return ULID{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ID generator '%s', expected %s or %s", kind, KindUUIDv7, KindULID)
+	}
+}
+
+// Проверяем, что ID выдан одним из генераторов сервиса. Принимаются ID всех форматов,
+// чтобы смена генератора не делала недействительными уже выданные ID
+func Valid(id string) bool {
+	return uuidPattern.MatchString(id) || ulidPattern.MatchString(id) || legacyPattern.MatchString(id)
+}
+
+// UUIDv7 выдаёт UUID версии 7 (RFC 9562): 48 бит времени в миллисекундах и 74 случайных бита.
+// ID растут со временем, поэтому хорошо ложатся в индекс первичного ключа
+type UUIDv7 struct{}
+
+// Создаём новый UUIDv7
+func (UUIDv7) NewID() string {
+	var id [16]byte
+	putTimestamp(id[:6], time.Now())
+	readRandom(id[6:])
+
+	id[6] = (id[6] & 0x0f) | 0x70 // Версия 7
+	id[8] = (id[8] & 0x3f) | 0x80 // Вариант RFC 9562
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf[:])
+}
+
+// ULID выдаёт ULID: 48 бит времени в миллисекундах и 80 случайных бит в Crockford Base32
+type ULID struct{}
+
+// Создаём новый ULID
+func (ULID) NewID() string {
+	var id [16]byte
+	putTimestamp(id[:6], time.Now())
+	readRandom(id[6:])
+	return encodeULID(id)
+}
+
+// Кодируем 128 бит ULID в Crockford Base32
+func encodeULID(id [16]byte) string {
+	// 128 бит кодируются 26 символами по 5 бит, старший символ содержит 3 бита
+	var buf [26]byte
+	var bits uint
+	var acc uint32
+	pos := len(buf) - 1
+	for i := len(id) - 1; i >= 0; i-- {
+		acc |= uint32(id[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			buf[pos] = crockfordAlphabet[acc&0x1f]
+			pos--
+			acc >>= 5
+			bits -= 5
+		}
+	}
+	buf[0] = crockfordAlphabet[acc&0x1f]
+	return string(buf[:])
+}
+
+// Записываем время в миллисекундах в 6 байт big-endian
+func putTimestamp(dst []byte, now time.Time) {
+	ms := uint64(now.UnixMilli())
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// Заполняем буфер криптографически стойкими случайными байтами
+func readRandom(dst []byte) {
+	if _, err := rand.Read(dst); err != nil {
+		// crypto/rand не возвращает ошибок на поддерживаемых платформах
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+}
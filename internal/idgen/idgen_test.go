@@ -0,0 +1,115 @@
+package idgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		expected Generator
+		wantErr  bool
+	}{
+		{name: "Default", kind: "", expected: UUIDv7{}},
+		{name: "UUIDv7", kind: "uuidv7", expected: UUIDv7{}},
+		{name: "ULID case insensitive", kind: " ULID ", expected: ULID{}},
+		{name: "Unknown", kind: "snowflake", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := New(tt.kind)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, generator)
+		})
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id := UUIDv7{}.NewID()
+
+	assert.Len(t, id, 36)
+	assert.True(t, Valid(id))
+	assert.Equal(t, byte('7'), id[14], "version nibble")
+	assert.Contains(t, "89ab", string(id[19]), "variant bits")
+
+	// Первые 48 бит — время создания в миллисекундах
+	var ms int64
+	for _, c := range strings.ReplaceAll(id[:13], "-", "") {
+		ms = ms<<4 | int64(strings.IndexRune("0123456789abcdef", c))
+	}
+	assert.GreaterOrEqual(t, ms, before)
+	assert.LessOrEqual(t, ms, time.Now().UnixMilli())
+}
+
+func TestULID(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id := ULID{}.NewID()
+
+	assert.Len(t, id, 26)
+	assert.True(t, Valid(id))
+
+	// Первые 10 символов — время создания в миллисекундах
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockfordAlphabet, c))
+	}
+	assert.GreaterOrEqual(t, ms, before)
+	assert.LessOrEqual(t, ms, time.Now().UnixMilli())
+}
+
+func TestEncodeULID(t *testing.T) {
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+
+	assert.Equal(t, "00000000000000000000000000", encodeULID([16]byte{}))
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(max))
+	assert.Equal(t, "0000000000000000000000000Z", encodeULID([16]byte{15: 0x1f}))
+}
+
+func TestGenerators_Unique(t *testing.T) {
+	for _, generator := range []Generator{UUIDv7{}, ULID{}} {
+		seen := make(map[string]bool)
+		for i := 0; i < 10000; i++ {
+			id := generator.NewID()
+			assert.False(t, seen[id], "duplicate ID %s", id)
+			seen[id] = true
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected bool
+	}{
+		{id: "01912d68-783e-7a1b-8f3c-4d5e6f708192", expected: true},
+		{id: "01ARZ3NDEKTSV4RRFFQ69G5FAV", expected: true},
+		{id: "1722334455667788990", expected: true},
+		{id: "", expected: false},
+		{id: "not-an-id", expected: false},
+		{id: "01912d68783e7a1b8f3c4d5e6f708192", expected: false},
+		{id: "81ARZ3NDEKTSV4RRFFQ69G5FAV", expected: false},
+		{id: "01ARZ3NDEKTSV4RRFFQ69G5FAU", expected: false},
+		{id: "12345678901234567890", expected: false},
+		{id: "' OR 1=1 --", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Valid(tt.id))
+		})
+	}
+}
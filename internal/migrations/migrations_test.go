@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name             string
+		files            fstest.MapFS
+		expectedVersions []int64
+		wantErr          bool
+	}{
+		{
+			name: "Sorted by version",
+			files: fstest.MapFS{
+				"0010_add_index.up.sql":   {Data: []byte("CREATE INDEX idx ON t(a);")},
+				"0010_add_index.down.sql": {Data: []byte("DROP INDEX idx;")},
+				"0002_create_t.up.sql":    {Data: []byte("CREATE TABLE t (a INT);")},
+				"0002_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+			},
+			expectedVersions: []int64{2, 10},
+		},
+		{
+			name: "Missing down file",
+			files: fstest.MapFS{
+				"0001_create_t.up.sql": {Data: []byte("CREATE TABLE t (a INT);")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Different names for one version",
+			files: fstest.MapFS{
+				"0001_create_t.up.sql":   {Data: []byte("CREATE TABLE t (a INT);")},
+				"0001_create_u.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid file name",
+			files: fstest.MapFS{
+				"create_t.sql": {Data: []byte("CREATE TABLE t (a INT);")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Zero version",
+			files: fstest.MapFS{
+				"0000_create_t.up.sql":   {Data: []byte("CREATE TABLE t (a INT);")},
+				"0000_create_t.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			var versions []int64
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, tt.expectedVersions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	embedded, err := fs.Sub(files, "sql")
+	assert.NoError(t, err)
+
+	migrations, err := Load(embedded)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	// Версии идут подряд, начиная с 1
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+	}
+	assert.Equal(t, "initial_schema", migrations[0].Name)
+	assert.Contains(t, migrations[0].Up, "CREATE TABLE IF NOT EXISTS quote_requests")
+
+	// Откат базовой схемы не трогает таблицы последующих миграций
+	assert.NotContains(t, migrations[0].Down, "quote_history")
+	assert.NotContains(t, migrations[0].Down, "webhook")
+}
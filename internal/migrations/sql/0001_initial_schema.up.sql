@@ -0,0 +1,31 @@
+-- Базовая схема сервиса: запросы на обновление и последние котировки пар.
+-- Таблицы создаются с IF NOT EXISTS, чтобы базы, созданные до появления миграций,
+-- переходили на них без изменений данных
+
+CREATE TABLE IF NOT EXISTS quote_requests (
+    id VARCHAR(36) PRIMARY KEY,
+    from_currency VARCHAR(10) NOT NULL,
+    to_currency VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS quotes (
+    id VARCHAR(36) PRIMARY KEY,
+    from_currency VARCHAR(10) NOT NULL,
+    to_currency VARCHAR(10) NOT NULL,
+    rate DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(from_currency, to_currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_requests_status ON quote_requests(status);
+CREATE INDEX IF NOT EXISTS idx_quotes_currencies ON quotes(from_currency, to_currency);
+CREATE INDEX IF NOT EXISTS idx_quote_requests_currencies ON quote_requests(from_currency, to_currency);
+
+-- Уникальный индекс для предотвращения дублирования pending запросов
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pending_quote_requests
+    ON quote_requests (from_currency, to_currency)
+    WHERE status = 'pending';
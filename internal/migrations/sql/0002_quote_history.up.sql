@@ -0,0 +1,10 @@
+-- История котировок: каждое обновление пары добавляет запись
+CREATE TABLE IF NOT EXISTS quote_history (
+    id BIGSERIAL PRIMARY KEY,
+    from_currency VARCHAR(10) NOT NULL,
+    to_currency VARCHAR(10) NOT NULL,
+    rate DECIMAL(20,8) NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_history_pair_time ON quote_history(from_currency, to_currency, recorded_at DESC, id DESC);
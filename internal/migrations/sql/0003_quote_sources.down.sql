@@ -0,0 +1,2 @@
+ALTER TABLE quote_history DROP COLUMN IF EXISTS sources;
+ALTER TABLE quotes DROP COLUMN IF EXISTS sources;
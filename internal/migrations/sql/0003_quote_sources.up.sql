@@ -0,0 +1,3 @@
+-- Провайдеры, из курсов которых рассчитана котировка
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS sources TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE quote_history ADD COLUMN IF NOT EXISTS sources TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_quote_requests_retry;
+
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS last_error;
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,6 @@
+-- Повторные попытки failed запросов с экспоненциальной задержкой
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_quote_requests_retry ON quote_requests(next_attempt_at) WHERE status = 'failed';
@@ -0,0 +1 @@
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS failure_reason;
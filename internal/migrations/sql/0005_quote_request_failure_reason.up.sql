@@ -0,0 +1,2 @@
+-- Машиночитаемая причина неудачи запроса
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(50) NOT NULL DEFAULT '';
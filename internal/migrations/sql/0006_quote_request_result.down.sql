@@ -0,0 +1,2 @@
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS quote_history_id;
+ALTER TABLE quote_requests DROP COLUMN IF EXISTS completed_at;
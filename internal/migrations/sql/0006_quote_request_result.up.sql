@@ -0,0 +1,3 @@
+-- Время завершения запроса и запись истории с его результатом
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quote_requests ADD COLUMN IF NOT EXISTS quote_history_id BIGINT REFERENCES quote_history(id);
@@ -0,0 +1,16 @@
+-- Список наблюдения: пары, обновляемые по расписанию
+CREATE TABLE IF NOT EXISTS watchlist (
+    id BIGSERIAL PRIMARY KEY,
+    from_currency VARCHAR(10) NOT NULL,
+    to_currency VARCHAR(10) NOT NULL,
+    schedule VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_enqueued_at TIMESTAMP WITH TIME ZONE,
+    last_request_id VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(from_currency, to_currency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_due ON watchlist(next_run_at) WHERE enabled;
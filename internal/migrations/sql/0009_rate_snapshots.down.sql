@@ -0,0 +1,4 @@
+ALTER TABLE quote_history DROP COLUMN IF EXISTS snapshot_id;
+ALTER TABLE quotes DROP COLUMN IF EXISTS snapshot_id;
+
+DROP TABLE IF EXISTS rate_snapshots;
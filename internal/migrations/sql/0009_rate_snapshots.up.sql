@@ -0,0 +1,14 @@
+-- Снимки курсов относительно USD и ссылки котировок на снимок, из которого они рассчитаны
+CREATE TABLE IF NOT EXISTS rate_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    upstream_date VARCHAR(40) NOT NULL DEFAULT '',
+    rates JSONB NOT NULL,
+    sources JSONB NOT NULL DEFAULT '{}',
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS snapshot_id BIGINT REFERENCES rate_snapshots(id);
+ALTER TABLE quote_history ADD COLUMN IF NOT EXISTS snapshot_id BIGINT REFERENCES rate_snapshots(id);
+
+CREATE INDEX IF NOT EXISTS idx_rate_snapshots_fetched ON rate_snapshots(fetched_at DESC, id DESC);
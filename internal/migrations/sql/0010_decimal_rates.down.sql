@@ -0,0 +1,3 @@
+-- Курсы округляются до 8 знаков после запятой
+ALTER TABLE quote_history ALTER COLUMN rate TYPE DECIMAL(20,8);
+ALTER TABLE quotes ALTER COLUMN rate TYPE DECIMAL(20,8);
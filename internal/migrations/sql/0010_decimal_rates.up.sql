@@ -0,0 +1,3 @@
+-- Точность курсов для точной десятичной арифметики: до 12 знаков после запятой
+ALTER TABLE quotes ALTER COLUMN rate TYPE DECIMAL(32,12);
+ALTER TABLE quote_history ALTER COLUMN rate TYPE DECIMAL(32,12);
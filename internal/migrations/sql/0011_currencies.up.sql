@@ -0,0 +1,10 @@
+-- Реестр валют, управляемый через админские эндпоинты
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(3) PRIMARY KEY,
+    numeric_code VARCHAR(3) NOT NULL DEFAULT '',
+    minor_units INTEGER NOT NULL DEFAULT 2,
+    name VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
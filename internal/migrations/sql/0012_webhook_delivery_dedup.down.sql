@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_event;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS status_changed_at;
@@ -0,0 +1,7 @@
+-- Смена статуса запроса, к которой относится доставка. Событие одной смены статуса
+-- ставится в outbox подписки не больше одного раза, даже если его добавили и воркер,
+-- и обработчик, проверивший статус после подписки
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+    ON webhook_deliveries (subscription_id, event, status_changed_at);
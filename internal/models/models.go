@@ -1,67 +1,307 @@
 package models
 
 import (
+	"encoding/xml"
+	"sort"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
-// Поддерживаемые валюты согласно заданию
+// Базовая валюта, относительно которой провайдеры возвращают курсы
+const USD = "USD"
+
+// Валюта реестра с метаданными ISO 4217
+type Currency struct {
+	Code        string    `json:"code" db:"code" example:"MXN"`                 // Буквенный код ISO 4217
+	NumericCode string    `json:"numeric_code" db:"numeric_code" example:"484"` // Цифровой код ISO 4217
+	MinorUnits  int       `json:"minor_units" db:"minor_units" example:"2"`     // Знаков после запятой в сумме
+	Name        string    `json:"name" db:"name" example:"Mexican Peso"`        // Название валюты
+	Enabled     bool      `json:"enabled" db:"enabled"`                         // Валюта доступна в API и запрашивается у провайдеров
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Запрос на добавление или изменение валюты реестра. Для известных кодов ISO 4217
+// незаполненные метаданные берутся из встроенного справочника
+type CurrencyRequest struct {
+	Code        string `json:"code,omitempty" example:"BRL"`
+	NumericCode string `json:"numeric_code,omitempty" example:"986"`
+	MinorUnits  *int   `json:"minor_units,omitempty" example:"2"`
+	Name        string `json:"name,omitempty" example:"Brazilian Real"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+}
+
+// Ответ со списком валют реестра
+type CurrenciesResponse struct {
+	Currencies []*Currency `json:"currencies"`
+}
+
+// Состояния котировки валютной пары относительно максимально допустимого возраста
 const (
-	USD = "USD"
-	EUR = "EUR"
-	MXN = "MXN"
+	PairStatusFresh = "fresh"
+	PairStatusStale = "stale"
 )
 
-// Список всех поддерживаемых валют
-var SupportedCurrencies = []string{USD, EUR, MXN}
+// Валютная пара с сохранённой котировкой
+type PairInfo struct {
+	Pair          string    `json:"pair" example:"EUR/MXN"`
+	From          string    `json:"from" example:"EUR"`
+	To            string    `json:"to" example:"MXN"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Status        string    `json:"status" example:"fresh"` // fresh или stale
+	AgeSeconds    int64     `json:"age_seconds"`
+	MaxAgeSeconds int64     `json:"max_age_seconds"` // 0 — возраст не ограничен
+}
 
-// Проверяем, поддерживается ли валюта
-func IsSupportedCurrency(currency string) bool {
-	return IsSupportedCurrencyFromList(currency, SupportedCurrencies)
+// Ответ со списком валютных пар
+type PairsResponse struct {
+	Pairs []PairInfo `json:"pairs"`
 }
 
-// Проверяем, поддерживается ли валюта из заданного списка
-func IsSupportedCurrencyFromList(currency string, supportedList []string) bool {
-	for _, supported := range supportedList {
-		if supported == currency {
-			return true
-		}
+// Причины неудачной обработки запроса на обновление котировки
+const (
+	FailureReasonUpstreamUnavailable = "upstream_unavailable"  // Провайдеры курсов недоступны
+	FailureReasonCurrencyNotInRates  = "currency_not_in_rates" // Провайдер не вернул курс одной из валют пары
+	FailureReasonInsufficientSources = "insufficient_sources"  // Согласованных источников курса меньше AGGREGATION_MIN_SOURCES
+	FailureReasonDBWriteFailed       = "db_write_failed"       // Не удалось сохранить котировку
+	FailureReasonCurrencyDisabled    = "currency_disabled"     // Валюта пары отключена в реестре после создания запроса
+)
+
+// Проверяем, что причина неудачи временная и запрос имеет смысл повторить.
+// Отсутствие курса валюты у провайдеров повтором не исправить
+func IsRetryableFailure(reason string) bool {
+	switch reason {
+	case FailureReasonUpstreamUnavailable, FailureReasonInsufficientSources, FailureReasonDBWriteFailed:
+		return true
+	default:
+		return false
 	}
-	return false
+}
+
+// Валютная пара
+type CurrencyPair struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Представление пары в виде FROM/TO
+func (p CurrencyPair) String() string {
+	return p.From + "/" + p.To
 }
 
 // Запрос на обновление котировки
 type QuoteRequest struct {
-	ID        string    `json:"id" db:"id"`
-	From      string    `json:"from" db:"from_currency"` // Базовая валюта (например, "EUR")
-	To        string    `json:"to" db:"to_currency"`     // Котируемая валюта (например, "MXN")
-	Status    string    `json:"status" db:"status"`      // pending, processing, completed, failed
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             string     `json:"id" db:"id"`
+	From           string     `json:"from" db:"from_currency"`                        // Базовая валюта (например, "EUR")
+	To             string     `json:"to" db:"to_currency"`                            // Котируемая валюта (например, "MXN")
+	Status         string     `json:"status" db:"status"`                             // pending, processing, completed, failed, dead
+	Attempts       int        `json:"attempts" db:"attempts"`                         // Количество неудачных попыток обработки
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"` // Время повторной попытки для failed запроса
+	FailureReason  string     `json:"failure_reason,omitempty" db:"failure_reason"`   // Причина последней неудачи (FailureReason*)
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`           // Ошибка последней попытки
+	CompletedAt    *time.Time `json:"completed_at,omitempty" db:"completed_at"`       // Время перехода в статус completed
+	QuoteHistoryID *int64     `json:"-" db:"quote_history_id"`                        // Запись истории с курсом, которым выполнен запрос
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // Котировка валютной пары
 type Quote struct {
-	ID        string    `json:"id" db:"id"`
-	From      string    `json:"from" db:"from_currency"` // Базовая валюта
-	To        string    `json:"to" db:"to_currency"`     // Котируемая валюта
-	Rate      float64   `json:"rate" db:"rate"`          // Курс обмена
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID         string          `json:"id" db:"id"`
+	From       string          `json:"from" db:"from_currency"`                               // Базовая валюта
+	To         string          `json:"to" db:"to_currency"`                                   // Котируемая валюта
+	Rate       decimal.Decimal `json:"rate" db:"rate" swaggertype:"string" example:"21.6271"` // Курс обмена
+	Sources    []string        `json:"sources" db:"sources"`                                  // Провайдеры, из курсов которых рассчитана котировка
+	SnapshotID *int64          `json:"snapshot_id,omitempty" db:"snapshot_id"`                // Снимок курсов, из которого рассчитана котировка
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Собираем ответ с котировкой
+func (q *Quote) Response() QuoteResponse {
+	return QuoteResponse{
+		ID:         q.ID,
+		From:       q.From,
+		To:         q.To,
+		Rate:       q.Rate,
+		Sources:    q.Sources,
+		SnapshotID: q.SnapshotID,
+		UpdatedAt:  q.UpdatedAt,
+	}
+}
+
+// Запись в истории котировок (append-only)
+type QuoteHistoryEntry struct {
+	ID         int64           `json:"id" db:"id"`
+	From       string          `json:"from" db:"from_currency"`
+	To         string          `json:"to" db:"to_currency"`
+	Rate       decimal.Decimal `json:"rate" db:"rate" swaggertype:"string" example:"21.6271"`
+	Sources    []string        `json:"sources" db:"sources"`
+	SnapshotID *int64          `json:"snapshot_id,omitempty" db:"snapshot_id"`
+	RecordedAt time.Time       `json:"recorded_at" db:"recorded_at"`
+}
+
+// Собираем элемент истории котировок для ответа
+func (e *QuoteHistoryEntry) Item() QuoteHistoryItem {
+	return QuoteHistoryItem{
+		Rate:       e.Rate,
+		Sources:    e.Sources,
+		SnapshotID: e.SnapshotID,
+		RecordedAt: e.RecordedAt,
+	}
+}
+
+// Собираем ответ с котировкой из записи истории, которой выполнен запрос с указанным ID
+func (e *QuoteHistoryEntry) Response(requestID string) QuoteResponse {
+	return QuoteResponse{
+		ID:         requestID,
+		From:       e.From,
+		To:         e.To,
+		Rate:       e.Rate,
+		Sources:    e.Sources,
+		SnapshotID: e.SnapshotID,
+		UpdatedAt:  e.RecordedAt,
+	}
+}
+
+// Позиция в истории котировок для курсорной пагинации
+type QuoteHistoryCursor struct {
+	RecordedAt time.Time
+	ID         int64
+}
+
+// Параметры выборки истории котировок
+type QuoteHistoryFilter struct {
+	From  string
+	To    string
+	Since *time.Time          // Включительно
+	Until *time.Time          // Включительно
+	After *QuoteHistoryCursor // Возвращаем записи строго старше курсора
+	Limit int
 }
 
 // Ответ с котировкой
 type QuoteResponse struct {
-	ID        string    `json:"id"`
-	From      string    `json:"from"`
-	To        string    `json:"to"`
-	Rate      float64   `json:"rate"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string          `json:"id"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Rate       decimal.Decimal `json:"rate" swaggertype:"string" example:"21.6271"`
+	Sources    []string        `json:"sources,omitempty"`
+	SnapshotID *int64          `json:"snapshot_id,omitempty"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+
+	// Цены с учётом спреда пары и наценки уровня клиента, mid совпадает с rate
+	Bid  *decimal.Decimal `json:"bid,omitempty" swaggertype:"string" example:"21.59465"`
+	Ask  *decimal.Decimal `json:"ask,omitempty" swaggertype:"string" example:"21.65955"`
+	Mid  *decimal.Decimal `json:"mid,omitempty" swaggertype:"string" example:"21.6271"`
+	Tier string           `json:"tier,omitempty" example:"retail"` // Уровень клиента, наценка которого применена
+
+	Freshness *QuoteFreshness `json:"freshness,omitempty"`
+}
+
+// Запрос на обновление котировок нескольких валютных пар
+type BatchUpdateQuoteRequest struct {
+	Pairs []CurrencyPair `json:"pairs"`
+}
+
+// Результат обновления одной пары в пакетном запросе
+type BatchUpdateQuoteResult struct {
+	Pair    string               `json:"pair" example:"EUR/MXN"`
+	Request *UpdateQuoteResponse `json:"request,omitempty"`
+	Error   *ErrorResponse       `json:"error,omitempty"`
+}
+
+// Ответ на пакетный запрос обновления котировок
+type BatchUpdateQuoteResponse struct {
+	Results []BatchUpdateQuoteResult `json:"results"`
+}
+
+// Последняя котировка одной пары в пакетном запросе
+type BatchQuoteResult struct {
+	Pair  string         `json:"pair" example:"EUR/MXN"`
+	Quote *QuoteResponse `json:"quote,omitempty"`
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+// Ответ с последними котировками нескольких пар
+type BatchLatestQuotesResponse struct {
+	Results []BatchQuoteResult `json:"results"`
+}
+
+// Актуальность котировки относительно максимально допустимого возраста
+type QuoteFreshness struct {
+	AgeSeconds       int64  `json:"age_seconds"`
+	MaxAgeSeconds    int64  `json:"max_age_seconds"`
+	Stale            bool   `json:"stale"`
+	RefreshRequestID string `json:"refresh_request_id,omitempty"` // ID запроса на обновление устаревшей котировки
+}
+
+// Ответ 503 с устаревшей котировкой
+type StaleQuoteErrorResponse struct {
+	Error   string        `json:"error"`
+	Message string        `json:"message"`
+	Quote   QuoteResponse `json:"quote"`
+}
+
+// Ответ с пересчётом суммы по последней котировке. Суммы передаются строками,
+// чтобы не терять точность при разборе JSON
+type ConversionResponse struct {
+	From            string          `json:"from" example:"EUR"`
+	To              string          `json:"to" example:"MXN"`
+	Amount          string          `json:"amount" example:"1234.56"`
+	ConvertedAmount string          `json:"converted_amount" example:"26699.95"`
+	Rate            decimal.Decimal `json:"rate" swaggertype:"string" example:"21.6271"`
+	Rounding        string          `json:"rounding" example:"half-even"`
+	MinorUnits      int             `json:"minor_units" example:"2"` // Знаков после запятой в валюте to по ISO 4217
+	QuoteID         string          `json:"quote_id"`
+	SnapshotID      *int64          `json:"snapshot_id,omitempty"`
+	QuoteUpdatedAt  time.Time       `json:"quote_updated_at"`
+	Freshness       *QuoteFreshness `json:"freshness,omitempty"`
+}
+
+// Событие обновления котировки в потоке /quotes/stream
+type QuoteUpdateEvent struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Rate      decimal.Decimal `json:"rate" swaggertype:"string" example:"21.6271"`
+	Sources   []string        `json:"sources,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Элемент истории котировок в ответе
+type QuoteHistoryItem struct {
+	Rate       decimal.Decimal `json:"rate" swaggertype:"string" example:"21.6271"`
+	Sources    []string        `json:"sources,omitempty"`
+	SnapshotID *int64          `json:"snapshot_id,omitempty"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// Ответ с историей котировок валютной пары
+type QuoteHistoryResponse struct {
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	Items      []QuoteHistoryItem `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Передаётся в параметре cursor для следующей страницы
+}
+
+// Ответ с курсом, действовавшим на заданный момент времени
+type QuoteAtResponse struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Rate        decimal.Decimal `json:"rate" swaggertype:"string" example:"21.6271"`
+	Sources     []string        `json:"sources,omitempty"`
+	SnapshotID  *int64          `json:"snapshot_id,omitempty"`
+	EffectiveAt time.Time       `json:"effective_at"` // Когда курс был получен сервисом
+	RequestedAt time.Time       `json:"requested_at"` // Момент времени, на который запрошен курс
+	AgeSeconds  int64           `json:"age_seconds"`  // Возраст курса на момент requested_at
 }
 
 // Запрос на обновление котировки
 type UpdateQuoteRequest struct {
-	From string `json:"from" validate:"required"` // Базовая валюта (например, "EUR")
-	To   string `json:"to" validate:"required"`   // Котируемая валюта (например, "MXN")
+	From        string `json:"from" validate:"required"` // Базовая валюта (например, "EUR")
+	To          string `json:"to" validate:"required"`   // Котируемая валюта (например, "MXN")
+	CallbackURL string `json:"callback_url,omitempty"`   // Адрес для webhook о завершении запроса (требует заголовок X-Client-ID)
 }
 
 // Запрос на получение котировки по ID
@@ -83,15 +323,297 @@ type ErrorResponse struct {
 
 // Ответ на запрос обновления котировки
 type UpdateQuoteResponse struct {
-	ID     string `json:"id"`
-	From   string `json:"from"`
-	To     string `json:"to"`
-	Status string `json:"status"`
+	ID            string     `json:"id"`
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Собираем ответ с состоянием запроса на обновление котировки
+func (r *QuoteRequest) StatusResponse() QuoteRequestStatusResponse {
+	return QuoteRequestStatusResponse{
+		ID:            r.ID,
+		From:          r.From,
+		To:            r.To,
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		NextAttemptAt: r.NextAttemptAt,
+		FailureReason: r.FailureReason,
+		LastError:     r.LastError,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+		CompletedAt:   r.CompletedAt,
+	}
+}
+
+// Собираем ответ на запрос обновления котировки
+func (r *QuoteRequest) UpdateResponse() UpdateQuoteResponse {
+	return UpdateQuoteResponse{
+		ID:            r.ID,
+		From:          r.From,
+		To:            r.To,
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		NextAttemptAt: r.NextAttemptAt,
+		FailureReason: r.FailureReason,
+		LastError:     r.LastError,
+	}
+}
+
+// Ответ с состоянием запроса на обновление котировки
+type QuoteRequestStatusResponse struct {
+	ID            string            `json:"id"`
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	FailureReason string            `json:"failure_reason,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty"`
+	Quote         *QuoteHistoryItem `json:"quote,omitempty"` // Курс, которым выполнен запрос (только для completed)
+}
+
+// Сообщение клиента WebSocket
+type WSClientMessage struct {
+	Type  string   `json:"type"`            // subscribe, unsubscribe, update, ping
+	ID    string   `json:"id,omitempty"`    // Произвольный ID, возвращается в ответе на сообщение
+	Pairs []string `json:"pairs,omitempty"` // Пары вида EUR/MXN для subscribe и unsubscribe
+	From  string   `json:"from,omitempty"`  // Базовая валюта для update
+	To    string   `json:"to,omitempty"`    // Котируемая валюта для update
+}
+
+// Сообщение сервера WebSocket
+type WSServerMessage struct {
+	Type    string                      `json:"type"` // subscribed, unsubscribed, quote, request_accepted, request_status, pong, error
+	ID      string                      `json:"id,omitempty"`
+	Pairs   []string                    `json:"pairs,omitempty"`
+	Quote   *QuoteUpdateEvent           `json:"quote,omitempty"`
+	Request *QuoteRequestStatusResponse `json:"request,omitempty"`
+	Error   string                      `json:"error,omitempty"`
+	Message string                      `json:"message,omitempty"`
+}
+
+// Статусы доставки webhook
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// Подписка клиента на изменения статуса запроса
+type WebhookSubscription struct {
+	ID          int64     `json:"id" db:"id"`
+	RequestID   string    `json:"request_id" db:"request_id"`
+	ClientID    string    `json:"client_id" db:"client_id"`
+	CallbackURL string    `json:"callback_url" db:"callback_url"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Доставка события webhook (outbox)
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	SubscriptionID int64      `json:"subscription_id" db:"subscription_id"`
+	RequestID      string     `json:"request_id" db:"request_id"`
+	ClientID       string     `json:"client_id" db:"client_id"`
+	CallbackURL    string     `json:"callback_url" db:"callback_url"`
+	Event          string     `json:"event" db:"event"`
+	Payload        []byte     `json:"-" db:"payload"`
+	Status         string     `json:"status" db:"status"` // pending, delivered, failed
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// Тело webhook о смене статуса запроса
+type WebhookPayload struct {
+	Event      string                     `json:"event"` // quote_request.completed, quote_request.failed, quote_request.dead
+	OccurredAt time.Time                  `json:"occurred_at"`
+	Data       QuoteRequestStatusResponse `json:"data"`
+}
+
+// Ответ с журналом доставок webhook по запросу
+type WebhookDeliveriesResponse struct {
+	RequestID  string             `json:"request_id"`
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
+
+// Валютная пара в списке наблюдения с расписанием автоматического обновления
+type WatchlistEntry struct {
+	ID             int64      `json:"id" db:"id"`
+	From           string     `json:"from" db:"from_currency"`
+	To             string     `json:"to" db:"to_currency"`
+	Schedule       string     `json:"schedule" db:"schedule"` // "@every 5m" или cron выражение "*/5 * * * *"
+	Enabled        bool       `json:"enabled" db:"enabled"`
+	NextRunAt      time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastEnqueuedAt *time.Time `json:"last_enqueued_at,omitempty" db:"last_enqueued_at"`
+	LastRequestID  string     `json:"last_request_id,omitempty" db:"last_request_id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Запрос на добавление или изменение пары в списке наблюдения.
+// Указывается либо interval, либо schedule
+type WatchlistEntryRequest struct {
+	From     string `json:"from,omitempty" example:"EUR"`
+	To       string `json:"to,omitempty" example:"MXN"`
+	Interval string `json:"interval,omitempty" example:"5m"`
+	Schedule string `json:"schedule,omitempty" example:"*/15 9-18 * * 1-5"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+// Ответ со списком наблюдения
+type WatchlistResponse struct {
+	Entries []*WatchlistEntry `json:"entries"`
+}
+
+// Состояния circuit breaker провайдера курсов
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// Состояние провайдера курсов в цепочке failover
+type ProviderStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"` // closed, open, half-open
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// Ответ со списком провайдеров курсов
+type ProvidersResponse struct {
+	Providers []ProviderStatus `json:"providers"`
+}
+
+// Курсы валют относительно USD, полученные от провайдера
+type ExchangeRates struct {
+	Provider string                     // Провайдер, вернувший курсы (или aggregate)
+	Date     string                     // Дата курсов по данным провайдера (поле date ответа)
+	Rates    map[string]decimal.Decimal // Курс каждой валюты относительно USD
+	Sources  map[string][]string        // Провайдеры, участвовавшие в расчёте курса каждой валюты
+
+	// Валюты, курс которых отброшен: согласованных источников меньше минимума агрегатора
+	InsufficientSources []string
+}
+
+// Проверяем, что курс одной из валют отброшен из-за нехватки согласованных источников.
+// В отличие от отсутствия курса, это временная ситуация: источники могут восстановиться
+func (r *ExchangeRates) HasInsufficientSources(currencies ...string) bool {
+	for _, currency := range currencies {
+		for _, insufficient := range r.InsufficientSources {
+			if currency == insufficient {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Возвращаем провайдеров, участвовавших в расчёте курсов указанных валют
+func (r *ExchangeRates) SourcesFor(currencies ...string) []string {
+	if len(r.Sources) == 0 {
+		return []string{r.Provider}
+	}
+
+	seen := make(map[string]bool)
+	var sources []string
+	for _, currency := range currencies {
+		for _, source := range r.Sources[currency] {
+			if !seen[source] {
+				seen[source] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+	sort.Strings(sources)
+
+	return sources
+}
+
+// Снимок курсов всех валют относительно USD, полученный воркером за один запрос к провайдерам
+type RatesSnapshot struct {
+	ID        int64 // 0, если снимок не удалось сохранить
+	Rates     *ExchangeRates
+	FetchedAt time.Time
+}
+
+// Собираем ответ со снимком курсов
+func (s *RatesSnapshot) Response() RateSnapshotResponse {
+	return RateSnapshotResponse{
+		ID:           s.ID,
+		Provider:     s.Rates.Provider,
+		UpstreamDate: s.Rates.Date,
+		Rates:        s.Rates.Rates,
+		Sources:      s.Rates.Sources,
+		FetchedAt:    s.FetchedAt,
+	}
+}
+
+// Ответ со снимком курсов относительно USD
+type RateSnapshotResponse struct {
+	ID           int64                      `json:"id"`
+	Provider     string                     `json:"provider"`
+	UpstreamDate string                     `json:"upstream_date,omitempty"`
+	Rates        map[string]decimal.Decimal `json:"rates" swaggertype:"object,string"`
+	Sources      map[string][]string        `json:"sources,omitempty"`
+	FetchedAt    time.Time                  `json:"fetched_at"`
+}
+
+// Матрица кросс-курсов: Rates[i][j] — курс Currencies[i]/Currencies[j] или nil,
+// если одной из валют нет в снимке курсов
+type QuoteMatrixResponse struct {
+	Currencies []string             `json:"currencies" example:"USD,EUR,MXN"`
+	Rates      [][]*decimal.Decimal `json:"rates" swaggertype:"array,string"`
+	Missing    []string             `json:"missing,omitempty" example:"MXN"` // Валюты, которых нет в снимке курсов
+	Provider   string               `json:"provider"`
+	Sources    []string             `json:"sources,omitempty"`
+	AsOf       time.Time            `json:"as_of"`
+	SnapshotID int64                `json:"snapshot_id,omitempty"`
+	Freshness  *QuoteFreshness      `json:"freshness,omitempty"` // Возраст снимка курсов, если задан максимальный возраст
 }
 
 // Ответ от внешнего API
 type ExternalAPIResponse struct {
-	Success bool               `json:"success"`
-	Rates   map[string]float64 `json:"rates"`
-	Date    string             `json:"date"`
+	Success bool                       `json:"success"`
+	Rates   map[string]decimal.Decimal `json:"rates"`
+	Date    string                     `json:"date"`
+}
+
+// Ежедневный курс ЕЦБ (eurofxref-daily.xml), курсы указаны относительно EUR
+type ECBEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Cube    struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string          `xml:"currency,attr"`
+				Rate     decimal.Decimal `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Ответ API в формате openexchangerates
+type OpenExchangeRatesResponse struct {
+	Timestamp   int64                      `json:"timestamp"`
+	Base        string                     `json:"base"`
+	Rates       map[string]decimal.Decimal `json:"rates"`
+	Error       bool                       `json:"error"`
+	Message     string                     `json:"message"`
+	Description string                     `json:"description"`
 }
@@ -0,0 +1,152 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// Сообщение, опубликованное в топик
+type Message struct {
+	Topic string
+	Data  interface{}
+}
+
+// Hub — внутрипроцессная шина сообщений между воркером и обработчиками.
+// Публикация не блокируется: если подписчик не успевает читать, сообщение
+// для него отбрасывается и учитывается в Dropped
+type Hub struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[uint64]*Subscription
+}
+
+// Подписка на набор топиков
+type Subscription struct {
+	hub    *Hub
+	id     uint64
+	topics map[string]bool
+	ch     chan Message
+
+	mu      sync.Mutex
+	closed  bool
+	dropped uint64
+}
+
+// Создаём новую шину сообщений
+func New() *Hub {
+	return &Hub{
+		subscribers: make(map[uint64]*Subscription),
+	}
+}
+
+// Подписываемся на топики. buffer задаёт размер очереди сообщений подписчика
+func (h *Hub) Subscribe(buffer int, topics ...string) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		topics: make(map[string]bool),
+		ch:     make(chan Message, buffer),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+
+	h.mu.Lock()
+	h.nextID++
+	sub.id = h.nextID
+	h.subscribers[sub.id] = sub
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Публикуем сообщение всем подписчикам топика
+func (h *Hub) Publish(topic string, data interface{}) {
+	if h == nil {
+		return
+	}
+
+	message := Message{Topic: topic, Data: data}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subscribers {
+		sub.deliver(message)
+	}
+}
+
+// Количество активных подписок
+func (h *Hub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Канал входящих сообщений. Закрывается после Close
+func (s *Subscription) C() <-chan Message {
+	return s.ch
+}
+
+// Добавляем топики в подписку
+func (s *Subscription) Add(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+}
+
+// Удаляем топики из подписки
+func (s *Subscription) Remove(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+}
+
+// Количество сообщений, отброшенных из-за переполнения очереди
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Отписываемся от всех топиков и закрываем канал
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subscribers, s.id)
+	s.hub.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Кладём сообщение в очередь подписчика, не блокируя публикацию
+func (s *Subscription) deliver(message Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || !s.topics[message.Topic] {
+		return
+	}
+
+	select {
+	case s.ch <- message:
+	default:
+		s.dropped++
+	}
+}
+
+// Топик обновлений котировки валютной пары
+func QuoteTopic(from, to string) string {
+	return "quote:" + from + "/" + to
+}
+
+// Топик смены статуса запроса на обновление котировки
+func RequestTopic(id string) string {
+	return "request:" + id
+}
@@ -0,0 +1,91 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_PublishToSubscribedTopics(t *testing.T) {
+	hub := New()
+	eurMXN := hub.Subscribe(10, "quote:EUR/MXN")
+	defer eurMXN.Close()
+	both := hub.Subscribe(10, "quote:EUR/MXN", "quote:USD/EUR")
+	defer both.Close()
+
+	hub.Publish("quote:EUR/MXN", 21.6)
+	hub.Publish("quote:USD/EUR", 0.92)
+	hub.Publish("quote:USD/MXN", 18.5)
+
+	assert.Equal(t, Message{Topic: "quote:EUR/MXN", Data: 21.6}, <-eurMXN.C())
+	assert.Len(t, eurMXN.C(), 0)
+
+	assert.Equal(t, Message{Topic: "quote:EUR/MXN", Data: 21.6}, <-both.C())
+	assert.Equal(t, Message{Topic: "quote:USD/EUR", Data: 0.92}, <-both.C())
+	assert.Len(t, both.C(), 0)
+}
+
+func TestHub_AddAndRemoveTopics(t *testing.T) {
+	hub := New()
+	sub := hub.Subscribe(10)
+	defer sub.Close()
+
+	hub.Publish("quote:EUR/MXN", 1)
+	sub.Add("quote:EUR/MXN")
+	hub.Publish("quote:EUR/MXN", 2)
+	sub.Remove("quote:EUR/MXN")
+	hub.Publish("quote:EUR/MXN", 3)
+
+	assert.Equal(t, 2, (<-sub.C()).Data)
+	assert.Len(t, sub.C(), 0)
+}
+
+func TestHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	hub := New()
+	sub := hub.Subscribe(1, "topic")
+	defer sub.Close()
+
+	hub.Publish("topic", 1)
+	hub.Publish("topic", 2)
+	hub.Publish("topic", 3)
+
+	assert.Equal(t, 1, (<-sub.C()).Data)
+	assert.Equal(t, uint64(2), sub.Dropped())
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := New()
+	sub := hub.Subscribe(1, "topic")
+	assert.Equal(t, 1, hub.SubscriberCount())
+
+	sub.Close()
+	sub.Close()
+	assert.Equal(t, 0, hub.SubscriberCount())
+
+	_, open := <-sub.C()
+	assert.False(t, open)
+
+	// Публикация после отписки не паникует
+	hub.Publish("topic", 1)
+}
+
+func TestHub_ConcurrentPublishAndClose(t *testing.T) {
+	hub := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub := hub.Subscribe(1, "topic")
+			for j := 0; j < 100; j++ {
+				hub.Publish("topic", j)
+			}
+			sub.Close()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, hub.SubscriberCount())
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Вычисляем задержку перед повторной попыткой с экспоненциальным ростом и jitter:
+// base * 2^(attempt-1), ограниченная max, из которой случайно выбирается значение
+// в диапазоне [delay/2, delay], чтобы повторы разных запросов не совпадали по времени
+func ExponentialBackoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		// Проверяем переполнение и верхнюю границу
+		if delay <= 0 || (max > 0 && delay >= max) {
+			delay = max
+			break
+		}
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		base    time.Duration
+		max     time.Duration
+		minWant time.Duration
+		maxWant time.Duration
+	}{
+		{name: "First attempt", attempt: 1, base: 10 * time.Second, max: 10 * time.Minute, minWant: 5 * time.Second, maxWant: 10 * time.Second},
+		{name: "Third attempt", attempt: 3, base: 10 * time.Second, max: 10 * time.Minute, minWant: 20 * time.Second, maxWant: 40 * time.Second},
+		{name: "Capped by max", attempt: 10, base: 10 * time.Second, max: time.Minute, minWant: 30 * time.Second, maxWant: time.Minute},
+		{name: "Huge attempt does not overflow", attempt: 200, base: time.Second, max: time.Hour, minWant: 30 * time.Minute, maxWant: time.Hour},
+		{name: "Zero attempt treated as first", attempt: 0, base: time.Second, max: time.Minute, minWant: 500 * time.Millisecond, maxWant: time.Second},
+		{name: "Zero base", attempt: 3, base: 0, max: time.Minute, minWant: 0, maxWant: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				result := ExponentialBackoff(tt.attempt, tt.base, tt.max)
+				if result < tt.minWant || result > tt.maxWant {
+					t.Fatalf("ExponentialBackoff() = %v, expected between %v and %v", result, tt.minWant, tt.maxWant)
+				}
+			}
+		})
+	}
+}
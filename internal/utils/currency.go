@@ -1,28 +1,37 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
 
-// Вычисляем курс валютной пары используя курсы относительно USD
-func CalculateExchangeRate(from, to string, usdRates map[string]float64) (float64, error) {
+	"github.com/shopspring/decimal"
+)
+
+// Вычисляем курс валютной пары используя курсы относительно USD.
+// Результат округляется до precision знаков после запятой
+func CalculateExchangeRate(from, to string, usdRates map[string]decimal.Decimal, precision int32) (decimal.Decimal, error) {
 	fromRate, fromExists := usdRates[from]
 	if !fromExists {
-		return 0, fmt.Errorf("currency %s not found in rates", from)
+		return decimal.Zero, fmt.Errorf("currency %s not found in rates", from)
 	}
 
 	toRate, toExists := usdRates[to]
 	if !toExists {
-		return 0, fmt.Errorf("currency %s not found in rates", to)
+		return decimal.Zero, fmt.Errorf("currency %s not found in rates", to)
+	}
+
+	if !fromRate.IsPositive() || !toRate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("invalid rates for %s/%s: %s, %s", from, to, fromRate, toRate)
 	}
 
 	// Вычисляем курс пары from/to
 	// API возвращает курсы относительно USD
-	var rate float64
+	var rate decimal.Decimal
 	if from == "USD" {
-		rate = toRate
+		rate = toRate.Round(precision)
 	} else if to == "USD" {
-		rate = 1.0 / fromRate
+		rate = decimal.NewFromInt(1).DivRound(fromRate, precision)
 	} else {
-		rate = toRate / fromRate
+		rate = toRate.DivRound(fromRate, precision)
 	}
 
 	return rate, nil
@@ -2,112 +2,114 @@ package utils
 
 import (
 	"testing"
+
+	"github.com/shopspring/decimal"
 )
 
+// Точность курсов в тестах, совпадает со значением по умолчанию RATE_PRECISION
+const testPrecision = 8
+
 func TestCalculateExchangeRate(t *testing.T) {
 	// Тестовые данные - курсы относительно USD (только поддерживаемые валюты)
-	usdRates := map[string]float64{
-		"USD": 1.0,
-		"EUR": 0.85,
-		"MXN": 18.5,
-	}
+	usdRates := rates(map[string]string{
+		"USD": "1",
+		"EUR": "0.85",
+		"MXN": "18.5",
+	})
 
 	tests := []struct {
 		name     string
 		from     string
 		to       string
-		expected float64
+		expected string
 		wantErr  bool
 	}{
 		{
 			name:     "USD to EUR",
 			from:     "USD",
 			to:       "EUR",
-			expected: 0.85,
+			expected: "0.85",
 			wantErr:  false,
 		},
 		{
 			name:     "EUR to USD",
 			from:     "EUR",
 			to:       "USD",
-			expected: 1.0 / 0.85, // ≈ 1.176
+			expected: "1.17647059", // 1 / 0.85
 			wantErr:  false,
 		},
 		{
 			name:     "USD to MXN",
 			from:     "USD",
 			to:       "MXN",
-			expected: 18.5,
+			expected: "18.5",
 			wantErr:  false,
 		},
 		{
 			name:     "MXN to USD",
 			from:     "MXN",
 			to:       "USD",
-			expected: 1.0 / 18.5, // ≈ 0.054
+			expected: "0.05405405", // 1 / 18.5
 			wantErr:  false,
 		},
 		{
 			name:     "EUR to MXN (cross rate)",
 			from:     "EUR",
 			to:       "MXN",
-			expected: 18.5 / 0.85, // ≈ 21.765
+			expected: "21.76470588", // 18.5 / 0.85
 			wantErr:  false,
 		},
 		{
 			name:     "MXN to EUR (cross rate)",
 			from:     "MXN",
 			to:       "EUR",
-			expected: 0.85 / 18.5, // ≈ 0.046
+			expected: "0.04594595", // 0.85 / 18.5
 			wantErr:  false,
 		},
 		{
 			name:     "USD to USD (same currency)",
 			from:     "USD",
 			to:       "USD",
-			expected: 1.0,
+			expected: "1",
 			wantErr:  false,
 		},
 		{
 			name:     "EUR to EUR (same currency)",
 			from:     "EUR",
 			to:       "EUR",
-			expected: 1.0,
+			expected: "1",
 			wantErr:  false,
 		},
 		{
 			name:     "MXN to MXN (same currency)",
 			from:     "MXN",
 			to:       "MXN",
-			expected: 1.0,
+			expected: "1",
 			wantErr:  false,
 		},
 		{
-			name:     "Non-existent from currency",
-			from:     "CAD",
-			to:       "USD",
-			expected: 0,
-			wantErr:  true,
+			name:    "Non-existent from currency",
+			from:    "CAD",
+			to:      "USD",
+			wantErr: true,
 		},
 		{
-			name:     "Non-existent to currency",
-			from:     "USD",
-			to:       "CAD",
-			expected: 0,
-			wantErr:  true,
+			name:    "Non-existent to currency",
+			from:    "USD",
+			to:      "CAD",
+			wantErr: true,
 		},
 		{
-			name:     "Both currencies non-existent",
-			from:     "CAD",
-			to:       "AUD",
-			expected: 0,
-			wantErr:  true,
+			name:    "Both currencies non-existent",
+			from:    "CAD",
+			to:      "AUD",
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := CalculateExchangeRate(tt.from, tt.to, usdRates)
+			result, err := CalculateExchangeRate(tt.from, tt.to, usdRates, testPrecision)
 
 			if tt.wantErr {
 				if err == nil {
@@ -121,9 +123,8 @@ func TestCalculateExchangeRate(t *testing.T) {
 				return
 			}
 
-			// Используем приблизительное сравнение для float64
-			if !isApproximatelyEqual(result, tt.expected, 0.0001) {
-				t.Errorf("CalculateExchangeRate() = %v, expected %v", result, tt.expected)
+			if !result.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("CalculateExchangeRate() = %s, expected %s", result, tt.expected)
 			}
 		})
 	}
@@ -131,92 +132,105 @@ func TestCalculateExchangeRate(t *testing.T) {
 
 func TestCalculateExchangeRate_EdgeCases(t *testing.T) {
 	t.Run("Empty rates map", func(t *testing.T) {
-		_, err := CalculateExchangeRate("USD", "EUR", map[string]float64{})
+		_, err := CalculateExchangeRate("USD", "EUR", map[string]decimal.Decimal{}, testPrecision)
 		if err == nil {
 			t.Error("Expected error for empty rates map")
 		}
 	})
 
 	t.Run("Nil rates map", func(t *testing.T) {
-		_, err := CalculateExchangeRate("USD", "EUR", nil)
+		_, err := CalculateExchangeRate("USD", "EUR", nil, testPrecision)
 		if err == nil {
 			t.Error("Expected error for nil rates map")
 		}
 	})
 
-	t.Run("Very small rates", func(t *testing.T) {
-		rates := map[string]float64{
-			"USD": 1.0,
-			"VND": 0.000043, // Вьетнамский донг
+	t.Run("Zero rate", func(t *testing.T) {
+		_, err := CalculateExchangeRate("EUR", "MXN", rates(map[string]string{"EUR": "0", "MXN": "18.5"}), testPrecision)
+		if err == nil {
+			t.Error("Expected error for zero rate")
 		}
+	})
 
-		result, err := CalculateExchangeRate("USD", "VND", rates)
+	t.Run("Very small rates", func(t *testing.T) {
+		result, err := CalculateExchangeRate("USD", "VND", rates(map[string]string{
+			"USD": "1",
+			"VND": "0.000043", // Вьетнамский донг
+		}), testPrecision)
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		expected := 0.000043
-		if !isApproximatelyEqual(result, expected, 0.0000001) {
-			t.Errorf("CalculateExchangeRate() = %v, expected %v", result, expected)
+		if !result.Equal(decimal.RequireFromString("0.000043")) {
+			t.Errorf("CalculateExchangeRate() = %s, expected 0.000043", result)
 		}
 	})
 
 	t.Run("Very large rates", func(t *testing.T) {
-		rates := map[string]float64{
-			"USD": 1.0,
-			"IRR": 42000.0, // Иранский риал
-		}
-
-		result, err := CalculateExchangeRate("USD", "IRR", rates)
+		result, err := CalculateExchangeRate("USD", "IRR", rates(map[string]string{
+			"USD": "1",
+			"IRR": "42000", // Иранский риал
+		}), testPrecision)
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		expected := 42000.0
-		if !isApproximatelyEqual(result, expected, 0.1) {
-			t.Errorf("CalculateExchangeRate() = %v, expected %v", result, expected)
+		if !result.Equal(decimal.NewFromInt(42000)) {
+			t.Errorf("CalculateExchangeRate() = %s, expected 42000", result)
 		}
 	})
 }
 
 func TestCalculateExchangeRate_Precision(t *testing.T) {
 	// Тест на точность вычислений с реальными курсами (только поддерживаемые валюты)
-	rates := map[string]float64{
-		"USD": 1.0,
-		"EUR": 0.8534,
-		"MXN": 18.4567,
-	}
+	usdRates := rates(map[string]string{
+		"USD": "1",
+		"EUR": "0.8534",
+		"MXN": "18.4567",
+	})
 
-	t.Run("High precision cross rate EUR to MXN", func(t *testing.T) {
-		result, err := CalculateExchangeRate("EUR", "MXN", rates)
-		if err != nil {
-			t.Errorf("Unexpected error: %v", err)
-		}
+	tests := []struct {
+		name      string
+		from      string
+		to        string
+		precision int32
+		expected  string
+	}{
+		{name: "Cross rate EUR to MXN", from: "EUR", to: "MXN", precision: 8, expected: "21.62725568"},
+		{name: "Cross rate MXN to EUR", from: "MXN", to: "EUR", precision: 8, expected: "0.04623795"},
+		{name: "Lower precision", from: "EUR", to: "MXN", precision: 4, expected: "21.6273"},
+		{name: "Provider rate rounded to precision", from: "USD", to: "MXN", precision: 2, expected: "18.46"},
+	}
 
-		expected := 18.4567 / 0.8534 // ≈ 21.627
-		if !isApproximatelyEqual(result, expected, 0.001) {
-			t.Errorf("CalculateExchangeRate() = %v, expected %v", result, expected)
-		}
-	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CalculateExchangeRate(tt.from, tt.to, usdRates, tt.precision)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !result.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("CalculateExchangeRate() = %s, expected %s", result, tt.expected)
+			}
+		})
+	}
 
-	t.Run("High precision cross rate MXN to EUR", func(t *testing.T) {
-		result, err := CalculateExchangeRate("MXN", "EUR", rates)
+	// В float64 0.3 / 0.1 = 2.9999999999999996, в десятичной арифметике курс точный
+	t.Run("No binary rounding drift", func(t *testing.T) {
+		result, err := CalculateExchangeRate("AAA", "BBB", rates(map[string]string{"AAA": "0.1", "BBB": "0.3"}), testPrecision)
 		if err != nil {
-			t.Errorf("Unexpected error: %v", err)
+			t.Fatalf("Unexpected error: %v", err)
 		}
-
-		expected := 0.8534 / 18.4567 // ≈ 0.046
-		if !isApproximatelyEqual(result, expected, 0.001) {
-			t.Errorf("CalculateExchangeRate() = %v, expected %v", result, expected)
+		if result.String() != "3" {
+			t.Errorf("CalculateExchangeRate() = %s, expected 3", result)
 		}
 	})
 }
 
-// Проверяем, что два float64 значения приблизительно равны
-func isApproximatelyEqual(a, b, tolerance float64) bool {
-	diff := a - b
-	if diff < 0 {
-		diff = -diff
+// Собираем курсы из строковых значений
+func rates(values map[string]string) map[string]decimal.Decimal {
+	result := make(map[string]decimal.Decimal, len(values))
+	for currency, value := range values {
+		result[currency] = decimal.RequireFromString(value)
 	}
-	return diff <= tolerance
+	return result
 }
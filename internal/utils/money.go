@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Режим округления суммы до минимальных единиц валюты
+type RoundingMode string
+
+const (
+	RoundingHalfEven RoundingMode = "half-even" // Банковское округление: половина к чётному
+	RoundingHalfUp   RoundingMode = "half-up"   // Половина от нуля
+	RoundingDown     RoundingMode = "down"      // Отбрасывание дробной части (к нулю)
+)
+
+// Количество минимальных единиц по ISO 4217 для валют, у которых оно отличается от 2
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Неотрицательная десятичная сумма без экспоненты: 1234, 1234.56
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Получаем количество знаков после запятой в сумме валюты по ISO 4217
+func CurrencyExponent(currency string) int {
+	if exponent, exists := currencyExponents[strings.ToUpper(currency)]; exists {
+		return exponent
+	}
+	return 2
+}
+
+// Разбираем режим округления. Пустое значение означает half-even
+func ParseRoundingMode(raw string) (RoundingMode, error) {
+	switch mode := RoundingMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "":
+		return RoundingHalfEven, nil
+	case RoundingHalfEven, RoundingHalfUp, RoundingDown:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid rounding mode '%s', expected half-even, half-up or down", raw)
+	}
+}
+
+// Разбираем сумму в валюте: положительное десятичное число, в котором знаков
+// после запятой не больше, чем минимальных единиц валюты
+func ParseAmount(raw string, exponent int) (decimal.Decimal, error) {
+	raw = strings.TrimSpace(raw)
+	if !amountPattern.MatchString(raw) {
+		return decimal.Zero, fmt.Errorf("invalid amount '%s', expected a positive decimal number like 1234.56", raw)
+	}
+
+	if dot := strings.IndexByte(raw, '.'); dot >= 0 && len(raw)-dot-1 > exponent {
+		return decimal.Zero, fmt.Errorf("amount '%s' has more than %d decimal places", raw, exponent)
+	}
+
+	amount, err := decimal.NewFromString(raw)
+	if err != nil || !amount.IsPositive() {
+		return decimal.Zero, fmt.Errorf("invalid amount '%s', expected a positive decimal number like 1234.56", raw)
+	}
+
+	return amount, nil
+}
+
+// Пересчитываем сумму по курсу и округляем до минимальных единиц целевой валюты
+func ConvertAmount(amount, rate decimal.Decimal, exponent int, mode RoundingMode) (string, error) {
+	if !rate.IsPositive() {
+		return "", fmt.Errorf("invalid rate %s", rate)
+	}
+
+	converted := amount.Mul(rate)
+	return RoundToMinorUnits(converted, exponent, mode).StringFixed(int32(exponent)), nil
+}
+
+// Округляем значение до exponent знаков после запятой в выбранном режиме
+func RoundToMinorUnits(value decimal.Decimal, exponent int, mode RoundingMode) decimal.Decimal {
+	switch mode {
+	case RoundingHalfUp:
+		return value.Round(int32(exponent))
+	case RoundingDown:
+		return value.Truncate(int32(exponent))
+	default:
+		return value.RoundBank(int32(exponent))
+	}
+}
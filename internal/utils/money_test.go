@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCurrencyExponent(t *testing.T) {
+	tests := map[string]int{
+		"USD": 2,
+		"EUR": 2,
+		"MXN": 2,
+		"JPY": 0,
+		"krw": 0,
+		"KWD": 3,
+		"CLF": 4,
+		"XXX": 2,
+	}
+
+	for currency, expected := range tests {
+		if got := CurrencyExponent(currency); got != expected {
+			t.Errorf("CurrencyExponent(%s) = %d, expected %d", currency, got, expected)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected RoundingMode
+		wantErr  bool
+	}{
+		{raw: "", expected: RoundingHalfEven},
+		{raw: "half-even", expected: RoundingHalfEven},
+		{raw: "HALF-UP", expected: RoundingHalfUp},
+		{raw: " down ", expected: RoundingDown},
+		{raw: "up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRoundingMode(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRoundingMode(%q) expected error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRoundingMode(%q) unexpected error: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseRoundingMode(%q) = %s, expected %s", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		exponent int
+		expected string
+		wantErr  bool
+	}{
+		{name: "Integer", raw: "1234", exponent: 2, expected: "1234"},
+		{name: "Minor units", raw: "1234.56", exponent: 2, expected: "1234.56"},
+		{name: "Fewer decimals", raw: "0.5", exponent: 2, expected: "0.5"},
+		{name: "Too many decimals", raw: "1.234", exponent: 2, wantErr: true},
+		{name: "Decimals for zero exponent", raw: "100.5", exponent: 0, wantErr: true},
+		{name: "Zero", raw: "0.00", exponent: 2, wantErr: true},
+		{name: "Negative", raw: "-10", exponent: 2, wantErr: true},
+		{name: "Exponent notation", raw: "1e3", exponent: 2, wantErr: true},
+		{name: "Fraction", raw: "1/3", exponent: 2, wantErr: true},
+		{name: "Empty", raw: "", exponent: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.raw, tt.exponent)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseAmount(%q) expected error", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q) unexpected error: %v", tt.raw, err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("ParseAmount(%q) = %s, expected %s", tt.raw, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRoundToMinorUnits(t *testing.T) {
+	tests := []struct {
+		value    string
+		exponent int
+		mode     RoundingMode
+		expected string
+	}{
+		{value: "2.345", exponent: 2, mode: RoundingHalfEven, expected: "2.34"},
+		{value: "2.355", exponent: 2, mode: RoundingHalfEven, expected: "2.36"},
+		{value: "2.345", exponent: 2, mode: RoundingHalfUp, expected: "2.35"},
+		{value: "2.349", exponent: 2, mode: RoundingDown, expected: "2.34"},
+		{value: "2.3451", exponent: 2, mode: RoundingHalfEven, expected: "2.35"},
+		{value: "-2.345", exponent: 2, mode: RoundingHalfUp, expected: "-2.35"},
+		{value: "-2.349", exponent: 2, mode: RoundingDown, expected: "-2.34"},
+		{value: "152.5", exponent: 0, mode: RoundingHalfEven, expected: "152"},
+		{value: "153.5", exponent: 0, mode: RoundingHalfEven, expected: "154"},
+		{value: "1.23456", exponent: 3, mode: RoundingHalfUp, expected: "1.235"},
+	}
+
+	for _, tt := range tests {
+		value := decimal.RequireFromString(tt.value)
+		got := RoundToMinorUnits(value, tt.exponent, tt.mode).StringFixed(int32(tt.exponent))
+		if got != tt.expected {
+			t.Errorf("RoundToMinorUnits(%s, %d, %s) = %s, expected %s", tt.value, tt.exponent, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	amount := decimal.RequireFromString("1234.56")
+
+	tests := []struct {
+		name     string
+		rate     string
+		exponent int
+		mode     RoundingMode
+		expected string
+	}{
+		// 1234.56 * 21.6271 = 26699.952576
+		{name: "EUR to MXN", rate: "21.6271", exponent: 2, mode: RoundingHalfEven, expected: "26699.95"},
+		// 1234.56 * 0.1 = 123.456
+		{name: "Exact decimal rate", rate: "0.1", exponent: 2, mode: RoundingHalfUp, expected: "123.46"},
+		{name: "Rounding down", rate: "0.1", exponent: 2, mode: RoundingDown, expected: "123.45"},
+		// 1234.56 * 151.25 = 186727.2 -> 186727 JPY
+		{name: "Zero minor units", rate: "151.25", exponent: 0, mode: RoundingHalfEven, expected: "186727"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertAmount(amount, decimal.RequireFromString(tt.rate), tt.exponent, tt.mode)
+			if err != nil {
+				t.Fatalf("ConvertAmount unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ConvertAmount = %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ConvertAmount(amount, decimal.Zero, 2, RoundingHalfEven); err == nil {
+		t.Error("ConvertAmount with zero rate expected error")
+	}
+}
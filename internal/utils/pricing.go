@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Количество базисных пунктов в единице
+var basisPointsPerUnit = decimal.NewFromInt(10000)
+
+// Рассчитываем bid и ask от среднего курса. Спред делится поровну между сторонами,
+// наценка добавляется к каждой стороне. bid округляется вниз, ask вверх, чтобы
+// округление не сужало спред
+func ApplySpread(mid, spreadBps, markupBps decimal.Decimal, precision int32) (decimal.Decimal, decimal.Decimal, error) {
+	if !mid.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("invalid mid rate %s", mid)
+	}
+	if spreadBps.IsNegative() || markupBps.IsNegative() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("spread and markup must not be negative")
+	}
+
+	// Отклонение каждой стороны от среднего курса в долях единицы
+	halfWidth := spreadBps.Div(decimal.NewFromInt(2)).
+		Add(markupBps).
+		Div(basisPointsPerUnit)
+	if halfWidth.IsZero() {
+		return mid, mid, nil
+	}
+
+	bid := mid.Mul(decimal.NewFromInt(1).Sub(halfWidth)).RoundFloor(precision)
+	ask := mid.Mul(decimal.NewFromInt(1).Add(halfWidth)).RoundCeil(precision)
+	if !bid.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("spread %s bps with markup %s bps leaves no positive bid for rate %s", spreadBps, markupBps, mid)
+	}
+
+	return bid, ask, nil
+}
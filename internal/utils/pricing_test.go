@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestApplySpread(t *testing.T) {
+	tests := []struct {
+		name        string
+		mid         string
+		spreadBps   string
+		markupBps   string
+		precision   int32
+		expectedBid string
+		expectedAsk string
+		wantErr     bool
+	}{
+		{name: "No spread", mid: "21.62725568", precision: 4, expectedBid: "21.62725568", expectedAsk: "21.62725568"},
+		{name: "Spread only", mid: "20", spreadBps: "10", precision: 8, expectedBid: "19.99", expectedAsk: "20.01"},
+		{name: "Spread with markup", mid: "20", spreadBps: "10", markupBps: "20", precision: 8, expectedBid: "19.95", expectedAsk: "20.05"},
+		{name: "Fractional basis points", mid: "1", spreadBps: "0.5", precision: 8, expectedBid: "0.999975", expectedAsk: "1.000025"},
+		{name: "Rounding widens spread", mid: "21.6271", spreadBps: "30", precision: 4, expectedBid: "21.5946", expectedAsk: "21.6596"},
+		{name: "Spread consumes bid", mid: "20", spreadBps: "20000", precision: 8, wantErr: true},
+		{name: "Negative markup", mid: "20", markupBps: "-5", precision: 8, wantErr: true},
+		{name: "Zero mid", mid: "0", spreadBps: "10", precision: 8, wantErr: true},
+	}
+
+	// Пустое значение в таблице означает отсутствие спреда или наценки
+	bps := func(value string) decimal.Decimal {
+		if value == "" {
+			return decimal.Zero
+		}
+		return decimal.RequireFromString(value)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bid, ask, err := ApplySpread(decimal.RequireFromString(tt.mid), bps(tt.spreadBps), bps(tt.markupBps), tt.precision)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ApplySpread() expected error, got bid %s ask %s", bid, ask)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplySpread() unexpected error: %v", err)
+			}
+			if !bid.Equal(decimal.RequireFromString(tt.expectedBid)) || !ask.Equal(decimal.RequireFromString(tt.expectedAsk)) {
+				t.Errorf("ApplySpread() = %s/%s, expected %s/%s", bid, ask, tt.expectedBid, tt.expectedAsk)
+			}
+		})
+	}
+}
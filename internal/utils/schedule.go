@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule определяет время следующего запуска
+type Schedule interface {
+	// Next возвращает ближайшее время запуска строго после after
+	Next(after time.Time) time.Time
+}
+
+// Разбираем расписание: интервал вида "@every 5m" или cron выражение
+// из пяти полей "минута час день месяц день_недели" (время UTC)
+func ParseSchedule(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval in %q: %w", expr, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("interval in %q must be at least 1s", expr)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields: minute hour day month weekday", expr)
+	}
+
+	schedule := cronSchedule{}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if schedule.day, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day field: %w", err)
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if schedule.weekday, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid weekday field: %w", err)
+	}
+	// Воскресенье можно задать как 0 или 7
+	if schedule.weekday&(1<<7) != 0 {
+		schedule.weekday |= 1
+	}
+	// Как в Vixie cron: поле, начинающееся с "*" (в том числе "*/2"), не считается ограничением
+	// для правила «день месяца или день недели»
+	schedule.dayRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.weekdayRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+// Расписание с фиксированным интервалом
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// Cron расписание. Значения полей хранятся битовыми масками
+type cronSchedule struct {
+	minute, hour, day, month, weekday uint64
+	dayRestricted, weekdayRestricted  bool
+}
+
+// Максимальный горизонт поиска: расписание вроде "0 0 30 2 *" никогда не сработает
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+func (s cronSchedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// Если ограничены и день месяца, и день недели, достаточно совпадения любого из них
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	dayMatch := s.day&(1<<uint(t.Day())) != 0
+	weekdayMatch := s.weekday&(1<<uint(t.Weekday())) != 0
+
+	if s.dayRestricted && s.weekdayRestricted {
+		return dayMatch || weekdayMatch
+	}
+	return dayMatch && weekdayMatch
+}
+
+// Разбираем поле cron: *, число, диапазон a-b, шаг */n или a-b/n и списки через запятую
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			rangePart = part[:slash]
+			value, err := strconv.Atoi(part[slash+1:])
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = value
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			start, end = value, value
+			// Шаг от одного значения означает "от значения до конца диапазона"
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			mask |= 1 << uint(value)
+		}
+	}
+
+	return mask, nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	// Воскресенье, 28 сентября 2025
+	after := time.Date(2025, 9, 28, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{name: "Every interval", expr: "@every 5m", expected: after.Add(5 * time.Minute)},
+		{name: "Every minute", expr: "* * * * *", expected: time.Date(2025, 9, 28, 10, 31, 0, 0, time.UTC)},
+		{name: "Every 15 minutes", expr: "*/15 * * * *", expected: time.Date(2025, 9, 28, 10, 45, 0, 0, time.UTC)},
+		{name: "Top of hour", expr: "0 * * * *", expected: time.Date(2025, 9, 28, 11, 0, 0, 0, time.UTC)},
+		{name: "Business hours", expr: "0 9-17 * * 1-5", expected: time.Date(2025, 9, 29, 9, 0, 0, 0, time.UTC)},
+		{name: "List of minutes", expr: "10,40 10 * * *", expected: time.Date(2025, 9, 28, 10, 40, 0, 0, time.UTC)},
+		{name: "Next month", expr: "0 0 1 * *", expected: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Sunday as 7", expr: "0 12 * * 7", expected: time.Date(2025, 9, 28, 12, 0, 0, 0, time.UTC)},
+		{name: "Day or weekday", expr: "0 0 15 * 3", expected: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Day step with weekday", expr: "0 0 */2 * 2", expected: time.Date(2025, 10, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "Step from value", expr: "5/20 * * * *", expected: time.Date(2025, 9, 28, 10, 45, 0, 0, time.UTC)},
+		{name: "Leap day", expr: "0 0 29 2 *", expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) unexpected error: %v", tt.expr, err)
+			}
+
+			result := schedule.Next(after)
+			if !result.Equal(tt.expected) {
+				t.Errorf("Next() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_NeverFires(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule() unexpected error: %v", err)
+	}
+
+	if result := schedule.Next(time.Now()); !result.IsZero() {
+		t.Errorf("Next() = %v, expected zero time", result)
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		"@every",
+		"@every 5x",
+		"@every 100ms",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseSchedule(expr); err == nil {
+				t.Errorf("ParseSchedule(%q) expected error", expr)
+			}
+		})
+	}
+}
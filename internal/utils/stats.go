@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"math"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// Вычисляем медиану значений
+func Median(values []decimal.Decimal) decimal.Decimal {
+	if len(values) == 0 {
+		return decimal.Zero
+	}
+
+	sorted := sortedCopy(values)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return sorted[middle-1].Add(sorted[middle]).Div(decimal.NewFromInt(2))
+	}
+	return sorted[middle]
+}
+
+// Вычисляем усечённое среднее: отбрасываем долю trimRatio значений с каждого края
+func TrimmedMean(values []decimal.Decimal, trimRatio float64) decimal.Decimal {
+	if len(values) == 0 {
+		return decimal.Zero
+	}
+
+	sorted := sortedCopy(values)
+	trim := int(math.Floor(float64(len(sorted)) * trimRatio))
+	// Оставляем хотя бы одно значение
+	if 2*trim >= len(sorted) {
+		trim = (len(sorted) - 1) / 2
+	}
+
+	kept := sorted[trim : len(sorted)-trim]
+	return decimal.Sum(kept[0], kept[1:]...).Div(decimal.NewFromInt(int64(len(kept))))
+}
+
+// Вычисляем относительное отклонение значения от эталона. Отклонение сравнивается
+// с порогом из конфигурации, поэтому возвращается как float64
+func RelativeDeviation(value, reference decimal.Decimal) float64 {
+	if reference.IsZero() {
+		return math.Inf(1)
+	}
+	return value.Sub(reference).Abs().Div(reference.Abs()).InexactFloat64()
+}
+
+// Копируем и сортируем значения, не изменяя исходный срез
+func sortedCopy(values []decimal.Decimal) []decimal.Decimal {
+	sorted := make([]decimal.Decimal, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LessThan(sorted[j]) })
+	return sorted
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{name: "Empty", values: nil, expected: "0"},
+		{name: "Single value", values: []string{"18.5"}, expected: "18.5"},
+		{name: "Odd count", values: []string{"18.7", "18.5", "25.0"}, expected: "18.7"},
+		{name: "Even count", values: []string{"18.4", "18.6", "18.5", "18.7"}, expected: "18.55"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Median(decimals(tt.values...))
+			if !result.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("Median() = %s, expected %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMedian_DoesNotModifyInput(t *testing.T) {
+	values := decimals("3", "1", "2")
+	Median(values)
+
+	if values[0].String() != "3" || values[1].String() != "1" || values[2].String() != "2" {
+		t.Errorf("Median() modified input: %v", values)
+	}
+}
+
+func TestTrimmedMean(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		trimRatio float64
+		expected  string
+	}{
+		{name: "Empty", values: nil, trimRatio: 0.2, expected: "0"},
+		{name: "No trimming", values: []string{"1", "2", "3"}, trimRatio: 0, expected: "2"},
+		{name: "Trim extremes", values: []string{"100", "1", "2", "3", "-50"}, trimRatio: 0.2, expected: "2"},
+		{name: "Trim keeps at least one value", values: []string{"1", "2"}, trimRatio: 0.5, expected: "1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TrimmedMean(decimals(tt.values...), tt.trimRatio)
+			if !result.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("TrimmedMean() = %s, expected %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRelativeDeviation(t *testing.T) {
+	if result := RelativeDeviation(decimal.RequireFromString("18.87"), decimal.RequireFromString("18.5")); result != 0.02 {
+		t.Errorf("RelativeDeviation() = %v, expected 0.02", result)
+	}
+
+	if result := RelativeDeviation(decimal.NewFromInt(1), decimal.Zero); result < 1e300 {
+		t.Errorf("RelativeDeviation() with zero reference = %v, expected +Inf", result)
+	}
+}
+
+// Собираем десятичные значения из строк
+func decimals(values ...string) []decimal.Decimal {
+	result := make([]decimal.Decimal, 0, len(values))
+	for _, value := range values {
+		result = append(result, decimal.RequireFromString(value))
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Диапазоны, не покрытые методами net.IP, но недоступные из интернета
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // Адреса «этой» сети
+	"100.64.0.0/10", // Carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // Тестирование производительности сетей
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Проверяем, что адрес публичный: не loopback, не link-local, не из частных
+// и служебных диапазонов. Webhook на такие адреса открыл бы доступ во внутреннюю сеть
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Проверяем хост адреса webhook без разрешения DNS: IP-адрес должен быть публичным,
+// имена localhost запрещены. Адрес, в который разрешается имя, проверяется при отправке
+func CheckCallbackHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("callback host %s is not allowed", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return fmt.Errorf("callback address %s is not a public address", host)
+	}
+	return nil
+}
+
+// Проверяем адрес, к которому подключается диспетчер, уже после разрешения DNS.
+// Так запрос не уйдёт во внутреннюю сеть ни через DNS rebinding, ни через редирект
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid callback address %s: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("callback address %s is not a public address", host)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Заголовки webhook запроса
+const (
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256(secret, timestamp + "." + body)>
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix время подписи в секундах
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery" // ID доставки, одинаковый для всех попыток
+)
+
+// Store определяет хранилище outbox доставок webhook
+type Store interface {
+	ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	MarkWebhookDelivered(id int64, statusCode int) error
+	RecordWebhookDeliveryFailure(id int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error
+}
+
+// Dispatcher доставляет события из outbox получателям с повторными попытками
+type Dispatcher struct {
+	store      Store
+	httpClient *http.Client
+	secrets    map[string]string
+	logger     *logrus.Logger
+	done       chan struct{}
+	stopOnce   sync.Once
+	cfg        config.WebhookConfig
+	now        func() time.Time
+}
+
+// Создаём новый диспетчер webhook
+func NewDispatcher(store Store, cfg *config.WebhookConfig, logger *logrus.Logger) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = checkDialAddress
+	}
+
+	// Прокси не используется: иначе проверялся бы адрес прокси, а не получателя
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: cfg.Timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &Dispatcher{
+		store: store,
+		httpClient: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+		},
+		secrets: cfg.Secrets,
+		logger:  logger,
+		done:    make(chan struct{}),
+		cfg:     *cfg,
+		now:     time.Now,
+	}
+}
+
+// Вычисляем подпись тела webhook
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Проверяем подпись webhook на стороне получателя
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Запускаем диспетчер
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.logger.Info("Starting webhook dispatcher")
+
+	ticker := time.NewTicker(d.cfg.Interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.DispatchDue()
+			case <-d.done:
+				d.logger.Info("Webhook dispatcher stopped")
+				return
+			case <-ctx.Done():
+				d.logger.Info("Webhook dispatcher context cancelled")
+				return
+			}
+		}
+	}()
+}
+
+// Стопаем диспетчер
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() { close(d.done) })
+}
+
+// Отправляем все доставки, время которых наступило
+func (d *Dispatcher) DispatchDue() {
+	claimedAt := d.now()
+	lease := d.batchLease()
+	deliveries, err := d.store.ClaimDueWebhookDeliveries(claimedAt, lease, d.cfg.BatchSize)
+	if err != nil {
+		d.logger.WithError(err).Error("Failed to claim webhook deliveries")
+		return
+	}
+
+	// Доставку начинаем, только если аренда переживёт её HTTP запрос. Остальные
+	// доставки пачки другая реплика или следующий проход заберут после истечения аренды
+	deadline := claimedAt.Add(lease - d.cfg.Timeout)
+	for i, delivery := range deliveries {
+		if d.now().After(deadline) {
+			d.logger.WithField("remaining", len(deliveries)-i).Warn("Webhook delivery lease is about to expire, leaving the rest of the batch")
+			return
+		}
+		d.deliver(delivery)
+	}
+}
+
+// Доставки пачки отправляются по очереди, и каждая занимает не больше Timeout.
+// Аренда покрывает всю пачку с запасом в один Timeout на запись результата, чтобы
+// другая реплика не забрала доставки, до которых очередь ещё не дошла
+func (d *Dispatcher) batchLease() time.Duration {
+	return time.Duration(d.cfg.BatchSize+1) * d.cfg.Timeout
+}
+
+// Отправляем одну доставку и фиксируем результат
+func (d *Dispatcher) deliver(delivery *models.WebhookDelivery) {
+	fields := logrus.Fields{
+		"delivery_id": delivery.ID,
+		"request_id":  delivery.RequestID,
+		"client_id":   delivery.ClientID,
+		"event":       delivery.Event,
+	}
+
+	statusCode, err := d.send(delivery)
+	if err == nil {
+		if markErr := d.store.MarkWebhookDelivered(delivery.ID, statusCode); markErr != nil {
+			d.logger.WithError(markErr).WithFields(fields).Error("Failed to mark webhook delivered")
+			return
+		}
+		d.logger.WithFields(fields).Info("Webhook delivered")
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	status := models.WebhookPending
+	nextAttemptAt := d.now().Add(utils.ExponentialBackoff(attempts, d.cfg.RetryBaseDelay, d.cfg.RetryMaxDelay))
+	if attempts >= d.cfg.MaxAttempts {
+		status = models.WebhookFailed
+	}
+
+	if recordErr := d.store.RecordWebhookDeliveryFailure(delivery.ID, status, statusCode, err.Error(), nextAttemptAt); recordErr != nil {
+		d.logger.WithError(recordErr).WithFields(fields).Error("Failed to record webhook delivery failure")
+		return
+	}
+
+	fields["attempts"] = attempts
+	fields["status"] = status
+	d.logger.WithError(err).WithFields(fields).Warn("Webhook delivery failed")
+}
+
+// Выполняем подписанный POST запрос к получателю
+func (d *Dispatcher) send(delivery *models.WebhookDelivery) (int, error) {
+	secret, exists := d.secrets[delivery.ClientID]
+	if !exists {
+		return 0, fmt.Errorf("no webhook secret configured for client %q", delivery.ClientID)
+	}
+
+	req, err := http.NewRequest("POST", delivery.CallbackURL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Currency-Quote-Service/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Дочитываем тело, чтобы соединение могло быть переиспользовано
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,347 @@
+package webhook
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// Хранилище outbox в памяти
+type memoryStore struct {
+	mu         sync.Mutex
+	deliveries map[int64]*models.WebhookDelivery
+}
+
+func newMemoryStore(deliveries ...*models.WebhookDelivery) *memoryStore {
+	store := &memoryStore{deliveries: make(map[int64]*models.WebhookDelivery)}
+	for _, delivery := range deliveries {
+		store.deliveries[delivery.ID] = delivery
+	}
+	return store
+}
+
+func (s *memoryStore) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []*models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.WebhookPending && !delivery.NextAttemptAt.After(now) && len(claimed) < limit {
+			delivery.NextAttemptAt = now.Add(lease)
+			copied := *delivery
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkWebhookDelivered(id int64, statusCode int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.deliveries[id]
+	delivery.Status = models.WebhookDelivered
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	return nil
+}
+
+func (s *memoryStore) RecordWebhookDeliveryFailure(id int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.deliveries[id]
+	delivery.Status = status
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = lastError
+	delivery.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func newTestDispatcher(store Store, now *time.Time) *Dispatcher {
+	dispatcher := NewDispatcher(store, &config.WebhookConfig{
+		Secrets:        map[string]string{"acme": "s3cret"},
+		Timeout:        time.Second,
+		BatchSize:      10,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+		// Тестовые получатели слушают на 127.0.0.1
+		AllowPrivateNetworks: true,
+	}, logrus.New())
+	dispatcher.now = func() time.Time { return *now }
+	return dispatcher
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"quote_request.completed"}`)
+	signature := Sign("s3cret", 1759055400, body)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("s3cret", 1759055400, body, signature))
+	assert.False(t, Verify("other", 1759055400, body, signature))
+	assert.False(t, Verify("s3cret", 1759055401, body, signature))
+	assert.False(t, Verify("s3cret", 1759055400, []byte(`{}`), signature))
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	payload := []byte(`{"event":"quote_request.completed","data":{"id":"123"}}`)
+
+	var received struct {
+		body      []byte
+		signature string
+		timestamp string
+		event     string
+		delivery  string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.body, _ = io.ReadAll(r.Body)
+		received.signature = r.Header.Get(HeaderSignature)
+		received.timestamp = r.Header.Get(HeaderTimestamp)
+		received.event = r.Header.Get(HeaderEvent)
+		received.delivery = r.Header.Get(HeaderDelivery)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	store := newMemoryStore(&models.WebhookDelivery{
+		ID:            7,
+		RequestID:     "123",
+		ClientID:      "acme",
+		CallbackURL:   server.URL,
+		Event:         "quote_request.completed",
+		Payload:       payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: now,
+	})
+
+	newTestDispatcher(store, &now).DispatchDue()
+
+	assert.Equal(t, payload, received.body)
+	assert.Equal(t, "quote_request.completed", received.event)
+	assert.Equal(t, "7", received.delivery)
+
+	timestamp, err := strconv.ParseInt(received.timestamp, 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Unix(), timestamp)
+	assert.True(t, Verify("s3cret", timestamp, received.body, received.signature))
+
+	delivery := store.deliveries[7]
+	assert.Equal(t, models.WebhookDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+}
+
+func TestDispatcher_RetriesUntilMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	store := newMemoryStore(&models.WebhookDelivery{
+		ID:            1,
+		ClientID:      "acme",
+		CallbackURL:   server.URL,
+		Payload:       []byte(`{}`),
+		Status:        models.WebhookPending,
+		NextAttemptAt: now,
+	})
+	dispatcher := newTestDispatcher(store, &now)
+
+	// Первая попытка: доставка остаётся pending и откладывается
+	dispatcher.DispatchDue()
+	delivery := store.deliveries[1]
+	assert.Equal(t, models.WebhookPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	assert.True(t, delivery.NextAttemptAt.After(now))
+
+	// До наступления времени повтора доставка не отправляется
+	dispatcher.DispatchDue()
+	assert.Equal(t, 1, calls)
+
+	// Повторяем, пока попытки не исчерпаны
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Hour)
+		dispatcher.DispatchDue()
+	}
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, models.WebhookFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+
+	// Failed доставка больше не отправляется
+	now = now.Add(time.Hour)
+	dispatcher.DispatchDue()
+	assert.Equal(t, 3, calls)
+}
+
+func TestDispatcher_UnknownClient(t *testing.T) {
+	now := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	store := newMemoryStore(&models.WebhookDelivery{
+		ID:            1,
+		ClientID:      "unknown",
+		CallbackURL:   "http://127.0.0.1:1",
+		Payload:       []byte(`{}`),
+		Status:        models.WebhookPending,
+		NextAttemptAt: now,
+	})
+
+	newTestDispatcher(store, &now).DispatchDue()
+
+	delivery := store.deliveries[1]
+	assert.Equal(t, models.WebhookPending, delivery.Status)
+	assert.Contains(t, delivery.LastError, "no webhook secret configured")
+}
+
+func TestDispatcher_RejectsPrivateAddressAtSendTime(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	store := newMemoryStore(&models.WebhookDelivery{
+		ID:            9,
+		RequestID:     "123",
+		ClientID:      "acme",
+		CallbackURL:   server.URL,
+		Event:         "quote_request.completed",
+		Payload:       []byte(`{}`),
+		Status:        models.WebhookPending,
+		NextAttemptAt: now,
+	})
+
+	dispatcher := NewDispatcher(store, &config.WebhookConfig{
+		Secrets:        map[string]string{"acme": "s3cret"},
+		Timeout:        time.Second,
+		BatchSize:      10,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+	}, logrus.New())
+	dispatcher.now = func() time.Time { return now }
+	dispatcher.DispatchDue()
+
+	// Получатель на 127.0.0.1 не вызывается, доставка считается неудачной
+	assert.False(t, called)
+	delivery := store.deliveries[9]
+	assert.Equal(t, models.WebhookPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Contains(t, delivery.LastError, "not a public address")
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{ip: "93.184.216.34", expected: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", expected: true},
+		{ip: "127.0.0.1", expected: false},
+		{ip: "::1", expected: false},
+		{ip: "10.1.2.3", expected: false},
+		{ip: "172.16.0.1", expected: false},
+		{ip: "192.168.1.1", expected: false},
+		{ip: "169.254.169.254", expected: false},
+		{ip: "fe80::1", expected: false},
+		{ip: "fd00::1", expected: false},
+		{ip: "0.0.0.0", expected: false},
+		{ip: "100.64.0.1", expected: false},
+		{ip: "::ffff:127.0.0.1", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsPublicIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestCheckCallbackHost(t *testing.T) {
+	assert.NoError(t, CheckCallbackHost("client.example.com"))
+	assert.NoError(t, CheckCallbackHost("93.184.216.34"))
+	assert.Error(t, CheckCallbackHost("localhost"))
+	assert.Error(t, CheckCallbackHost("api.LOCALHOST."))
+	assert.Error(t, CheckCallbackHost("169.254.169.254"))
+	assert.Error(t, CheckCallbackHost("::1"))
+}
+
+// Хранилище, запоминающее аренду, с которой забирались доставки
+type leaseRecordingStore struct {
+	*memoryStore
+	lease time.Duration
+}
+
+func (s *leaseRecordingStore) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	s.lease = lease
+	return s.memoryStore.ClaimDueWebhookDeliveries(now, lease, limit)
+}
+
+func TestDispatcher_LeaseCoversBatch(t *testing.T) {
+	// Каждая доставка занимает у получателя 6 секунд при аренде (10+1)×1s
+	var mu sync.Mutex
+	now := time.Date(2025, 9, 28, 10, 30, 0, 0, time.UTC)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		now = now.Add(6 * time.Second)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for id := int64(1); id <= 3; id++ {
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			ID:            id,
+			ClientID:      "acme",
+			CallbackURL:   server.URL,
+			Payload:       []byte(`{}`),
+			Status:        models.WebhookPending,
+			NextAttemptAt: now,
+		})
+	}
+	store := &leaseRecordingStore{memoryStore: newMemoryStore(deliveries...)}
+
+	dispatcher := newTestDispatcher(store, &now)
+	dispatcher.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	dispatcher.DispatchDue()
+
+	// Аренда рассчитана на всю пачку, а не на одну доставку
+	assert.Equal(t, 11*time.Second, store.lease)
+
+	// Третья доставка не начинается: аренда истекла бы во время её запроса
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, calls)
+	delivered := 0
+	for _, delivery := range store.deliveries {
+		if delivery.Status == models.WebhookDelivered {
+			delivered++
+		}
+	}
+	assert.Equal(t, 2, delivered)
+}
@@ -2,11 +2,17 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
 	"time"
 
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
 	"go_plata_task_v2/internal/database"
 	"go_plata_task_v2/internal/external"
 	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
 	"go_plata_task_v2/internal/utils"
 
 	"github.com/sirupsen/logrus"
@@ -14,27 +20,51 @@ import (
 
 // Worker представляет фоновый воркер для обновления котировок
 type Worker struct {
-	db          *database.DB
-	externalAPI *external.Client
-	logger      *logrus.Logger
-	ticker      *time.Ticker
-	done        chan bool
-	interval    time.Duration
+	db           database.DatabaseInterface
+	rateProvider external.RateProvider
+	hub          *pubsub.Hub
+	logger       *logrus.Logger
+	ticker       *time.Ticker
+	done         chan bool
+	trigger      chan struct{}
+	interval     time.Duration
+
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+
+	currencies    *currency.Registry // Включённые валюты запрашиваются у провайдера при каждом обновлении
+	ratePrecision int32              // Количество знаков после запятой в рассчитанных курсах
+
+	snapshotInterval time.Duration // Максимальный возраст снимка курсов, после которого он обновляется без запросов
+	snapshotAt       atomic.Int64  // Время получения последнего известного снимка курсов (UnixNano, 0 — снимка нет)
 }
 
 // Создаём новый воркер
-func New(db *database.DB, externalAPI *external.Client, logger *logrus.Logger, interval time.Duration) *Worker {
+func New(db database.DatabaseInterface, rateProvider external.RateProvider, hub *pubsub.Hub, logger *logrus.Logger, cfg *config.WorkerConfig, currencies *currency.Registry, ratePrecision int32) *Worker {
 	return &Worker{
-		db:          db,
-		externalAPI: externalAPI,
-		logger:      logger,
-		done:        make(chan bool),
-		interval:    interval,
+		db:             db,
+		rateProvider:   rateProvider,
+		hub:            hub,
+		logger:         logger,
+		done:           make(chan bool),
+		trigger:        make(chan struct{}, 1),
+		interval:       cfg.Interval,
+		maxAttempts:    cfg.MaxAttempts,
+		retryBaseDelay: cfg.RetryBaseDelay,
+		retryMaxDelay:  cfg.RetryMaxDelay,
+		currencies:     currencies,
+		ratePrecision:  ratePrecision,
+
+		snapshotInterval: cfg.SnapshotInterval,
 	}
 }
 
 // Запускаем воркер
 func (w *Worker) Start(ctx context.Context) {
+	// Снимок курсов, сохранённый до перезапуска, сразу доступен матрице кросс-курсов
+	w.checkStoredRates()
+
 	w.logger.Info("Starting quote update worker")
 
 	// Запускаем воркер с настраиваемым интервалом
@@ -48,6 +78,8 @@ func (w *Worker) Start(ctx context.Context) {
 			select {
 			case <-w.ticker.C:
 				w.processPendingRequests()
+			case <-w.trigger:
+				w.processPendingRequests()
 			case <-w.done:
 				w.logger.Info("Worker stopped")
 				return
@@ -59,6 +91,15 @@ func (w *Worker) Start(ctx context.Context) {
 	}()
 }
 
+// Запускаем внеочередную обработку ожидающих запросов, не дожидаясь тикера.
+// Вызовы до начала обработки объединяются в один проход
+func (w *Worker) Trigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
 // Стопаем воркер
 func (w *Worker) Stop() {
 	if w.ticker != nil {
@@ -71,6 +112,12 @@ func (w *Worker) Stop() {
 func (w *Worker) processPendingRequests() {
 	w.logger.Debug("Processing pending quote requests")
 
+	// Ставим в очередь обновления пар из списка наблюдения по расписанию
+	w.enqueueWatchlistRefreshes()
+
+	// Возвращаем в очередь failed запросы, время повторной попытки которых наступило
+	w.requeueFailedRequests()
+
 	// Получаем все ожидающие запросы
 	requests, err := w.db.GetPendingQuoteRequests()
 	if err != nil {
@@ -80,20 +127,31 @@ func (w *Worker) processPendingRequests() {
 
 	if len(requests) == 0 {
 		w.logger.Debug("No pending quote requests found")
+
+		// Снимок курсов нужен матрице кросс-курсов и без запросов: получаем первый после запуска
+		// и обновляем его каждые snapshotInterval, чтобы матрица не устаревала
+		if w.snapshotDue() {
+			if _, err := w.fetchRates(nil); err != nil {
+				w.logger.WithError(err).WithField("provider", w.rateProvider.Name()).Warn("Failed to refresh rates snapshot")
+			}
+		}
 		return
 	}
 
 	w.logger.WithField("count", len(requests)).Info("Found pending quote requests")
 
-	// Собираем все уникальные валюты из запросов
-	currencies := w.extractUniqueCurrencies(requests)
+	// Запросы по отключённым валютам не отправляются провайдерам
+	requests = w.failDisabledCurrencyRequests(requests)
+	if len(requests) == 0 {
+		return
+	}
 
-	// Получаем все курсы одним batch запросом
-	usdRates, err := w.externalAPI.GetMultipleExchangeRates(currencies)
+	// Получаем курсы всех валют из запросов одним batch запросом
+	snapshot, err := w.fetchRates(requests)
 	if err != nil {
-		w.logger.WithError(err).Error("Failed to get batch exchange rates")
+		w.logger.WithError(err).WithField("provider", w.rateProvider.Name()).Error("Failed to get batch exchange rates")
 		// Помечаем все запросы как failed
-		w.markAllRequestsAsFailed(requests, err)
+		w.markAllRequestsAsFailed(requests, models.FailureReasonUpstreamUnavailable, err)
 		return
 	}
 
@@ -106,16 +164,103 @@ func (w *Worker) processPendingRequests() {
 
 	// Обрабатываем каждую валютную пару с использованием полученных курсов
 	for pair, reqs := range currencyPairMap {
-		w.processCurrencyPairWithRates(pair, reqs, usdRates)
+		w.processCurrencyPairWithRates(pair, reqs, snapshot)
+	}
+}
+
+// Получаем курсы валют из запросов вместе со всеми поддерживаемыми валютами
+// и сохраняем их как снимок курсов. Ошибка сохранения снимка не мешает обработке
+// запросов, котировки тогда остаются без ссылки на снимок
+func (w *Worker) fetchRates(requests []*models.QuoteRequest) (*models.RatesSnapshot, error) {
+	rates, err := w.rateProvider.GetMultipleExchangeRates(w.extractUniqueCurrencies(requests))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.RatesSnapshot{Rates: rates, FetchedAt: time.Now()}
+	if id, err := w.db.SaveRateSnapshot(snapshot); err != nil {
+		w.logger.WithError(err).WithField("provider", rates.Provider).Error("Failed to save rates snapshot")
+	} else {
+		snapshot.ID = id
+		w.snapshotAt.Store(snapshot.FetchedAt.UnixNano())
 	}
+
+	return snapshot, nil
 }
 
-// Извлекаем все уникальные валюты из запросов
+// Проверяем, сохранён ли в базе снимок курсов. Если нет, воркер получит его на первом проходе
+func (w *Worker) checkStoredRates() {
+	snapshot, err := w.db.GetLatestRateSnapshot()
+	if err != nil {
+		w.logger.WithError(err).Debug("No stored rates snapshot found")
+		return
+	}
+
+	w.snapshotAt.Store(snapshot.FetchedAt.UnixNano())
+	w.logger.WithFields(logrus.Fields{
+		"snapshot_id": snapshot.ID,
+		"fetched_at":  snapshot.FetchedAt,
+	}).Info("Found stored rates snapshot")
+}
+
+// Проверяем, нужно ли получить новый снимок курсов без запросов. Перед обращением
+// к провайдеру перечитываем последний снимок: его могла сохранить другая реплика
+func (w *Worker) snapshotDue() bool {
+	if !w.snapshotExpired() {
+		return false
+	}
+
+	if snapshot, err := w.db.GetLatestRateSnapshot(); err == nil {
+		w.snapshotAt.Store(snapshot.FetchedAt.UnixNano())
+	}
+	return w.snapshotExpired()
+}
+
+// Проверяем, что снимка нет или он старше snapshotInterval
+func (w *Worker) snapshotExpired() bool {
+	fetchedAt := w.snapshotAt.Load()
+	if fetchedAt == 0 {
+		return true
+	}
+	return w.snapshotInterval > 0 && time.Since(time.Unix(0, fetchedAt)) >= w.snapshotInterval
+}
+
+// Завершаем запросы, валюта которых отключена в реестре после их создания,
+// и возвращаем остальные
+func (w *Worker) failDisabledCurrencyRequests(requests []*models.QuoteRequest) []*models.QuoteRequest {
+	enabled := make([]*models.QuoteRequest, 0, len(requests))
+	for _, req := range requests {
+		disabled := ""
+		if !w.currencies.IsEnabled(req.From) {
+			disabled = req.From
+		} else if !w.currencies.IsEnabled(req.To) {
+			disabled = req.To
+		}
+
+		if disabled == "" {
+			enabled = append(enabled, req)
+			continue
+		}
+		w.markAllRequestsAsFailed([]*models.QuoteRequest{req}, models.FailureReasonCurrencyDisabled,
+			fmt.Errorf("currency %s is disabled in registry", disabled))
+	}
+
+	return enabled
+}
+
+// Извлекаем все уникальные валюты из запросов и включённых валют реестра.
+// Отключённые валюты у провайдеров не запрашиваются
 func (w *Worker) extractUniqueCurrencies(requests []*models.QuoteRequest) []string {
 	currencies := make(map[string]bool)
+	for _, currency := range w.currencies.Enabled() {
+		currencies[currency] = true
+	}
 	for _, req := range requests {
-		currencies[req.From] = true
-		currencies[req.To] = true
+		for _, code := range []string{req.From, req.To} {
+			if w.currencies.IsEnabled(code) {
+				currencies[code] = true
+			}
+		}
 	}
 
 	var result []string
@@ -126,31 +271,139 @@ func (w *Worker) extractUniqueCurrencies(requests []*models.QuoteRequest) []stri
 	return result
 }
 
-// Помечаем все запросы как failed
-func (w *Worker) markAllRequestsAsFailed(requests []*models.QuoteRequest, err error) {
+// Создаём запросы на обновление для пар списка наблюдения, время обновления которых
+// наступило. Точность расписания ограничена интервалом воркера
+func (w *Worker) enqueueWatchlistRefreshes() {
+	now := time.Now()
+
+	entries, err := w.db.GetDueWatchlistEntries(now)
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to get due watchlist entries")
+		return
+	}
+
+	for _, entry := range entries {
+		fields := logrus.Fields{
+			"watchlist_id": entry.ID,
+			"from":         entry.From,
+			"to":           entry.To,
+			"schedule":     entry.Schedule,
+		}
+
+		schedule, err := utils.ParseSchedule(entry.Schedule)
+		if err != nil {
+			w.logger.WithError(err).WithFields(fields).Error("Invalid watchlist schedule")
+			continue
+		}
+
+		// Запрос идемпотентен: если по паре уже есть pending запрос, используется он
+		request, err := w.db.CreateOrGetPendingQuoteRequest(entry.From, entry.To)
+		if err != nil {
+			w.logger.WithError(err).WithFields(fields).Error("Failed to enqueue watchlist refresh")
+			continue
+		}
+
+		// Расписание, которое больше не сработает, откладывается на максимальный горизонт
+		nextRunAt := schedule.Next(now)
+		if nextRunAt.IsZero() {
+			nextRunAt = now.AddDate(5, 0, 0)
+		}
+
+		if err := w.db.MarkWatchlistEntryEnqueued(entry.ID, now, request.ID, nextRunAt); err != nil {
+			w.logger.WithError(err).WithFields(fields).Error("Failed to update watchlist schedule")
+			continue
+		}
+
+		fields["request_id"] = request.ID
+		fields["next_run_at"] = nextRunAt
+		w.logger.WithFields(fields).Info("Watchlist refresh enqueued")
+	}
+}
+
+// Возвращаем в очередь failed запросы с наступившим временем повторной попытки
+func (w *Worker) requeueFailedRequests() {
+	count, err := w.db.RequeueFailedQuoteRequests(time.Now())
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to requeue failed quote requests")
+		return
+	}
+
+	if count > 0 {
+		w.logger.WithField("count", count).Info("Requeued failed quote requests for retry")
+	}
+}
+
+// Помечаем все запросы как failed с причиной и повторной попыткой по экспоненциальной
+// задержке, либо как dead, если попытки исчерпаны или причина неудачи постоянная
+func (w *Worker) markAllRequestsAsFailed(requests []*models.QuoteRequest, reason string, err error) {
 	for _, req := range requests {
-		if updateErr := w.db.UpdateQuoteRequestStatus(req.ID, "failed"); updateErr != nil {
-			w.logger.WithError(updateErr).WithField("request_id", req.ID).Error("Failed to update request status to failed")
+		attempts := req.Attempts + 1
+		status := "failed"
+		var nextAttemptAt *time.Time
+
+		if attempts >= w.maxAttempts || !models.IsRetryableFailure(reason) {
+			status = "dead"
+		} else {
+			next := time.Now().Add(utils.ExponentialBackoff(attempts, w.retryBaseDelay, w.retryMaxDelay))
+			nextAttemptAt = &next
+		}
+
+		if updateErr := w.db.RecordQuoteRequestFailure(req.ID, status, reason, err.Error(), nextAttemptAt); updateErr != nil {
+			w.logger.WithError(updateErr).WithField("request_id", req.ID).Errorf("Failed to update request status to %s", status)
+			continue
 		}
+
+		failed := *req
+		failed.Status = status
+		failed.Attempts = attempts
+		failed.NextAttemptAt = nextAttemptAt
+		failed.FailureReason = reason
+		failed.LastError = err.Error()
+		failed.UpdatedAt = time.Now()
+		w.notifyStatusChange(&failed, nil)
+
+		fields := logrus.Fields{
+			"request_id": req.ID,
+			"attempts":   attempts,
+			"status":     status,
+			"reason":     reason,
+		}
+		if nextAttemptAt != nil {
+			fields["next_attempt_at"] = *nextAttemptAt
+		}
+		w.logger.WithFields(fields).Warn("Quote request failed")
 	}
 }
 
 // Обрабатываем валютную пару используя предварительно полученные курсы
-func (w *Worker) processCurrencyPairWithRates(pair string, requests []*models.QuoteRequest, usdRates map[string]float64) {
+func (w *Worker) processCurrencyPairWithRates(pair string, requests []*models.QuoteRequest, snapshot *models.RatesSnapshot) {
 	w.logger.WithField("pair", pair).Debug("Processing currency pair requests with pre-fetched rates")
 
 	// Обновляем статус всех запросов на "processing"
 	for _, req := range requests {
 		if err := w.db.UpdateQuoteRequestStatus(req.ID, "processing"); err != nil {
 			w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to update request status to processing")
+			continue
 		}
+
+		processing := *req
+		processing.Status = "processing"
+		processing.UpdatedAt = time.Now()
+		w.hub.Publish(pubsub.RequestTopic(req.ID), processing.StatusResponse())
 	}
 
 	from := requests[0].From
 	to := requests[0].To
+	rates := snapshot.Rates
+
+	// Снимок курсов, из которого рассчитана котировка (если его удалось сохранить)
+	var snapshotID *int64
+	if snapshot.ID != 0 {
+		snapshotID = &snapshot.ID
+	}
 
 	// Вычисляем курс пары используя предварительно полученные курсы
-	rate, err := utils.CalculateExchangeRate(from, to, usdRates)
+	rate, err := utils.CalculateExchangeRate(from, to, rates.Rates, w.ratePrecision)
 	if err != nil {
 		w.logger.WithError(err).WithFields(logrus.Fields{
 			"pair": pair,
@@ -158,44 +411,111 @@ func (w *Worker) processCurrencyPairWithRates(pair string, requests []*models.Qu
 			"to":   to,
 		}).Error("Failed to calculate exchange rate")
 
-		// Обновляем статус всех запросов на "failed"
-		for _, req := range requests {
-			if err := w.db.UpdateQuoteRequestStatus(req.ID, "failed"); err != nil {
-				w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to update request status to failed")
-			}
+		// Нехватка согласованных источников временная, а отсутствие курса у провайдеров — нет
+		reason := models.FailureReasonCurrencyNotInRates
+		if rates.HasInsufficientSources(from, to) {
+			reason = models.FailureReasonInsufficientSources
 		}
+		w.markAllRequestsAsFailed(requests, reason, err)
 		return
 	}
 
+	// Провайдеры, из курсов которых рассчитана котировка
+	sources := rates.SourcesFor(from, to)
+
 	// Сохраняем котировку в базу данных
-	if err := w.db.UpsertQuote(from, to, rate); err != nil {
+	if err := w.db.UpsertQuote(from, to, rate, sources, snapshotID); err != nil {
 		w.logger.WithError(err).WithFields(logrus.Fields{
 			"pair": pair,
 			"from": from,
 			"to":   to,
 		}).Error("Failed to save quote to database")
 
-		// Обновляем статус всех запросов на "failed"
-		for _, req := range requests {
-			if err := w.db.UpdateQuoteRequestStatus(req.ID, "failed"); err != nil {
-				w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to update request status to failed")
-			}
-		}
+		// Помечаем все запросы как failed
+		w.markAllRequestsAsFailed(requests, models.FailureReasonDBWriteFailed, err)
 		return
 	}
 
-	// Обновляем статус всех запросов на "completed"
+	// Уведомляем подписчиков потока котировок
+	w.hub.Publish(pubsub.QuoteTopic(from, to), models.QuoteUpdateEvent{
+		From:      from,
+		To:        to,
+		Rate:      rate,
+		Sources:   sources,
+		UpdatedAt: time.Now(),
+	})
+
+	// Сохраняем курс в историю котировок. Ошибка не влияет на статус запросов,
+	// так как актуальная котировка уже сохранена
+	var quoteHistoryID *int64
+	if historyID, err := w.db.AppendQuoteHistory(from, to, rate, sources, snapshotID); err != nil {
+		w.logger.WithError(err).WithFields(logrus.Fields{
+			"pair": pair,
+			"from": from,
+			"to":   to,
+		}).Error("Failed to append quote history")
+	} else {
+		quoteHistoryID = &historyID
+	}
+
+	// Обновляем статус всех запросов на "completed" и связываем их с записью истории
+	completedAt := time.Now()
+	quote := &models.QuoteHistoryItem{Rate: rate, Sources: sources, SnapshotID: snapshotID, RecordedAt: completedAt}
 	for _, req := range requests {
-		if err := w.db.UpdateQuoteRequestStatus(req.ID, "completed"); err != nil {
+		if err := w.db.CompleteQuoteRequest(req.ID, quoteHistoryID); err != nil {
 			w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to update request status to completed")
+			continue
 		}
+
+		completed := *req
+		completed.Status = "completed"
+		completed.NextAttemptAt = nil
+		completed.CompletedAt = &completedAt
+		completed.UpdatedAt = completedAt
+		w.notifyStatusChange(&completed, quote)
 	}
 
 	w.logger.WithFields(logrus.Fields{
-		"pair":  pair,
-		"from":  from,
-		"to":    to,
-		"rate":  rate,
-		"count": len(requests),
+		"pair":    pair,
+		"from":    from,
+		"to":      to,
+		"rate":    rate,
+		"sources": sources,
+		"count":   len(requests),
 	}).Info("Successfully processed currency pair requests with batch rates")
 }
+
+// Уведомляем о смене статуса запроса: публикуем её в шину для подписчиков
+// в реальном времени и добавляем в outbox webhook для всех подписчиков запроса
+func (w *Worker) notifyStatusChange(req *models.QuoteRequest, quote *models.QuoteHistoryItem) {
+	data := req.StatusResponse()
+	data.Quote = quote
+
+	w.hub.Publish(pubsub.RequestTopic(req.ID), data)
+
+	payload := models.WebhookPayload{
+		Event:      "quote_request." + req.Status,
+		OccurredAt: req.UpdatedAt,
+		Data:       data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to marshal webhook payload")
+		return
+	}
+
+	count, err := w.db.EnqueueWebhookDeliveries(req.ID, payload.Event, body)
+	if err != nil {
+		w.logger.WithError(err).WithField("request_id", req.ID).Error("Failed to enqueue webhook deliveries")
+		return
+	}
+
+	if count > 0 {
+		w.logger.WithFields(logrus.Fields{
+			"request_id": req.ID,
+			"event":      payload.Event,
+			"count":      count,
+		}).Debug("Webhook deliveries enqueued")
+	}
+}
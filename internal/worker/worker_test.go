@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go_plata_task_v2/internal/config"
+	"go_plata_task_v2/internal/currency"
+	"go_plata_task_v2/internal/database"
+	"go_plata_task_v2/internal/models"
+	"go_plata_task_v2/internal/pubsub"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDB реализует методы базы данных, которые вызывает воркер в тестах.
+// Остальные методы DatabaseInterface не реализованы и паникуют при вызове
+type MockDB struct {
+	mock.Mock
+	database.DatabaseInterface
+}
+
+func (m *MockDB) RecordQuoteRequestFailure(id, status, reason, lastError string, nextAttemptAt *time.Time) error {
+	args := m.Called(id, status, reason, lastError, nextAttemptAt)
+	return args.Error(0)
+}
+
+func (m *MockDB) EnqueueWebhookDeliveries(requestID, event string, payload []byte) (int64, error) {
+	args := m.Called(requestID, event, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetLatestRateSnapshot() (*models.RatesSnapshot, error) {
+	args := m.Called()
+	return args.Get(0).(*models.RatesSnapshot), args.Error(1)
+}
+
+// Создаём воркер с тестовой базой данных и параметрами повторов
+func newTestWorker(db database.DatabaseInterface, hub *pubsub.Hub) *Worker {
+	return New(db, nil, hub, logrus.New(), &config.WorkerConfig{
+		Interval:         time.Minute,
+		MaxAttempts:      5,
+		RetryBaseDelay:   time.Second,
+		RetryMaxDelay:    time.Minute,
+		SnapshotInterval: 5 * time.Minute,
+	}, currency.NewStatic("USD", "EUR", "MXN"), 8)
+}
+
+func TestSnapshotDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		knownAge  time.Duration // Возраст снимка, известного воркеру (0 — снимка нет)
+		mockSetup func(*MockDB)
+		expected  bool
+	}{
+		{
+			name:     "Known snapshot is fresh",
+			knownAge: time.Minute,
+			mockSetup: func(mockDB *MockDB) {
+				// Пока снимок свежий, база данных не опрашивается
+			},
+		},
+		{
+			name: "No snapshot stored",
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetLatestRateSnapshot").Return((*models.RatesSnapshot)(nil), database.ErrNotFound)
+			},
+			expected: true,
+		},
+		{
+			name:     "Snapshot expired",
+			knownAge: 10 * time.Minute,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetLatestRateSnapshot").Return(&models.RatesSnapshot{ID: 1, FetchedAt: time.Now().Add(-10 * time.Minute)}, nil)
+			},
+			expected: true,
+		},
+		{
+			name:     "Another replica stored a fresh snapshot",
+			knownAge: 10 * time.Minute,
+			mockSetup: func(mockDB *MockDB) {
+				mockDB.On("GetLatestRateSnapshot").Return(&models.RatesSnapshot{ID: 2, FetchedAt: time.Now().Add(-time.Minute)}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			tt.mockSetup(mockDB)
+
+			worker := newTestWorker(mockDB, pubsub.New())
+			if tt.knownAge > 0 {
+				worker.snapshotAt.Store(time.Now().Add(-tt.knownAge).UnixNano())
+			}
+
+			assert.Equal(t, tt.expected, worker.snapshotDue())
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMarkAllRequestsAsFailed(t *testing.T) {
+	tests := []struct {
+		name           string
+		attempts       int
+		reason         string
+		expectedStatus string
+		expectRetry    bool
+	}{
+		{
+			name:           "Retryable reason below max attempts",
+			attempts:       1,
+			reason:         models.FailureReasonUpstreamUnavailable,
+			expectedStatus: "failed",
+			expectRetry:    true,
+		},
+		{
+			name:           "Insufficient sources is retried",
+			attempts:       0,
+			reason:         models.FailureReasonInsufficientSources,
+			expectedStatus: "failed",
+			expectRetry:    true,
+		},
+		{
+			name:           "Attempts exhausted",
+			attempts:       4,
+			reason:         models.FailureReasonUpstreamUnavailable,
+			expectedStatus: "dead",
+		},
+		{
+			name:           "Non-retryable reason",
+			attempts:       0,
+			reason:         models.FailureReasonCurrencyNotInRates,
+			expectedStatus: "dead",
+		},
+		{
+			name:           "Currency disabled",
+			attempts:       0,
+			reason:         models.FailureReasonCurrencyDisabled,
+			expectedStatus: "dead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := errors.New("rates unavailable")
+			request := &models.QuoteRequest{ID: "123", From: "EUR", To: "MXN", Status: "processing", Attempts: tt.attempts}
+
+			mockDB := new(MockDB)
+			mockDB.On("RecordQuoteRequestFailure", "123", tt.expectedStatus, tt.reason, failure.Error(),
+				mock.MatchedBy(func(nextAttemptAt *time.Time) bool {
+					// Повтор планируется в будущем, а dead запрос больше не повторяется
+					if !tt.expectRetry {
+						return nextAttemptAt == nil
+					}
+					return nextAttemptAt != nil && nextAttemptAt.After(time.Now())
+				})).Return(nil)
+			mockDB.On("EnqueueWebhookDeliveries", "123", "quote_request."+tt.expectedStatus, mock.Anything).Return(int64(1), nil)
+
+			hub := pubsub.New()
+			sub := hub.Subscribe(1, pubsub.RequestTopic("123"))
+			defer sub.Close()
+
+			worker := newTestWorker(mockDB, hub)
+			worker.markAllRequestsAsFailed([]*models.QuoteRequest{request}, tt.reason, failure)
+
+			// Подписчики получают новое состояние запроса
+			select {
+			case message := <-sub.C():
+				status, ok := message.Data.(models.QuoteRequestStatusResponse)
+				if assert.True(t, ok) {
+					assert.Equal(t, tt.expectedStatus, status.Status)
+					assert.Equal(t, tt.attempts+1, status.Attempts)
+					assert.Equal(t, tt.reason, status.FailureReason)
+				}
+			default:
+				t.Fatal("status change was not published")
+			}
+
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMarkAllRequestsAsFailed_RecordFailureError(t *testing.T) {
+	request := &models.QuoteRequest{ID: "123", From: "EUR", To: "MXN", Status: "processing"}
+
+	mockDB := new(MockDB)
+	mockDB.On("RecordQuoteRequestFailure", "123", "failed", models.FailureReasonDBWriteFailed, "write failed", mock.Anything).
+		Return(assert.AnError)
+
+	hub := pubsub.New()
+	sub := hub.Subscribe(1, pubsub.RequestTopic("123"))
+	defer sub.Close()
+
+	// Если состояние не сохранилось, о нём не сообщается ни подписчикам, ни webhook
+	worker := newTestWorker(mockDB, hub)
+	worker.markAllRequestsAsFailed([]*models.QuoteRequest{request}, models.FailureReasonDBWriteFailed, errors.New("write failed"))
+
+	assert.Empty(t, sub.C())
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNotCalled(t, "EnqueueWebhookDeliveries", mock.Anything, mock.Anything, mock.Anything)
+}